@@ -0,0 +1,78 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type ConditionStatus string
+
+const (
+	ConditionTrue    ConditionStatus = "True"
+	ConditionFalse   ConditionStatus = "False"
+	ConditionUnknown ConditionStatus = "Unknown"
+)
+
+// Condition describes one aspect of the observed state of an object.
+type Condition struct {
+	// Type of the condition, in CamelCase.
+	Type string `json:"type"`
+	// Status of the condition, one of True, False or Unknown.
+	Status ConditionStatus `json:"status"`
+	// ObservedGeneration is the .metadata.generation the condition was set based upon.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// LastTransitionTime is the last time the status changed.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// Reason is a machine readable explanation of the status, in CamelCase.
+	Reason string `json:"reason,omitempty"`
+	// Message is a human readable explanation of the status.
+	Message string `json:"message,omitempty"`
+}
+
+// SetCondition adds the given condition or replaces the condition of the same type.
+// LastTransitionTime is only updated, when the status changes.
+func SetCondition(conditions *[]Condition, condition Condition) {
+	for i := range *conditions {
+		existing := &(*conditions)[i]
+		if existing.Type != condition.Type {
+			continue
+		}
+
+		if existing.Status == condition.Status {
+			condition.LastTransitionTime = existing.LastTransitionTime
+		} else if condition.LastTransitionTime.IsZero() {
+			condition.LastTransitionTime = metav1.Now()
+		}
+		*existing = condition
+		return
+	}
+
+	if condition.LastTransitionTime.IsZero() {
+		condition.LastTransitionTime = metav1.Now()
+	}
+	*conditions = append(*conditions, condition)
+}
+
+// FindCondition returns the condition of the given type or nil, if not present.
+func FindCondition(conditions []Condition, conditionType string) *Condition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
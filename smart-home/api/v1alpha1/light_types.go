@@ -0,0 +1,71 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LightSpec defines the desired state of Light
+type LightSpec struct {
+	On bool `json:"on"`
+}
+
+type LightPhaseTypes string
+
+const (
+	LightOn  = "On"
+	LightOff = "Off"
+)
+
+const (
+	// LightReachable is False, when the Light can not be found in the smart home.
+	LightReachable = "Reachable"
+)
+
+// LightStatus defines the observed state of Light
+type LightStatus struct {
+	ObservedGeneration int64           `json:"observedGeneration,omitempty"`
+	Phase              LightPhaseTypes `json:"phase,omitempty"`
+	On                 bool            `json:"on"`
+	Conditions         []Condition     `json:"conditions,omitempty"`
+}
+
+// Light is the Schema for the lights API
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Target",type="boolean",JSONPath=".spec.on"
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type Light struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   LightSpec   `json:"spec,omitempty"`
+	Status LightStatus `json:"status,omitempty"`
+}
+
+// LightList contains a list of Light
+// +kubebuilder:object:root=true
+type LightList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Light `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Light{}, &LightList{})
+}
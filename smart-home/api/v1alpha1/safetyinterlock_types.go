@@ -0,0 +1,88 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SafetyInterlockSpec defines the desired state of SafetyInterlock
+type SafetyInterlockSpec struct {
+	// Sensor is the name of the smart home sensor to monitor, e.g. "wind-speed".
+	Sensor string `json:"sensor"`
+	// Threshold activates the interlock, when the sensor reading reaches it.
+	Threshold int `json:"threshold"`
+	// ReleaseThreshold releases the interlock, when the sensor reading drops below it.
+	// Defaults to Threshold. Set it lower, to keep gusts from toggling the interlock.
+	// It must not be above Threshold.
+	ReleaseThreshold *int `json:"releaseThreshold,omitempty"`
+	// Selector selects the Shutters in the namespace of the SafetyInterlock to lock.
+	// Selects all Shutters in the namespace, when empty.
+	Selector metav1.LabelSelector `json:"selector,omitempty"`
+	// SafeClosedPercentage is the position locked Shutters are forced to.
+	// Defaults to 0, fully retracted.
+	// When multiple active interlocks select a Shutter, the first by name applies.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	SafeClosedPercentage int `json:"safeClosedPercentage,omitempty"`
+}
+
+const (
+	// SafetyInterlockReady is False, when the spec of the interlock is invalid.
+	SafetyInterlockReady = "Ready"
+	// SafetyInterlockActive is True, while the interlock locks its Shutters.
+	SafetyInterlockActive = "Active"
+	// SafetyInterlockSensorReachable is False, when the sensor can not be read.
+	SafetyInterlockSensorReachable = "SensorReachable"
+)
+
+// SafetyInterlockStatus defines the observed state of SafetyInterlock
+type SafetyInterlockStatus struct {
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Active is true, while the interlock locks its Shutters.
+	Active bool `json:"active"`
+	// SensorValue is the last reading of the sensor.
+	SensorValue string      `json:"sensorValue,omitempty"`
+	Conditions  []Condition `json:"conditions,omitempty"`
+}
+
+// SafetyInterlock is the Schema for the safetyinterlocks API
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Sensor",type="string",JSONPath=".spec.sensor"
+// +kubebuilder:printcolumn:name="Value",type="string",JSONPath=".status.sensorValue"
+// +kubebuilder:printcolumn:name="Threshold",type="integer",JSONPath=".spec.threshold"
+// +kubebuilder:printcolumn:name="Active",type="boolean",JSONPath=".status.active"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type SafetyInterlock struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SafetyInterlockSpec   `json:"spec,omitempty"`
+	Status SafetyInterlockStatus `json:"status,omitempty"`
+}
+
+// SafetyInterlockList contains a list of SafetyInterlock
+// +kubebuilder:object:root=true
+type SafetyInterlockList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SafetyInterlock `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SafetyInterlock{}, &SafetyInterlockList{})
+}
@@ -0,0 +1,127 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SceneSpec defines the desired state of Scene
+type SceneSpec struct {
+	// Active applies the Scene to its devices, by setting the spec of their Shutters and Lights.
+	// An active Scene is applied again, whenever it changes.
+	// Locked Shutters are moved, once their safety interlock is released.
+	// To apply it again unchanged, deactivate and activate it.
+	Active bool `json:"active"`
+	// Shutters lists the Shutters in the namespace of the Scene and their positions.
+	Shutters []SceneShutter `json:"shutters,omitempty"`
+	// Lights lists the Lights in the namespace of the Scene and their states.
+	Lights []SceneLight `json:"lights,omitempty"`
+}
+
+// SceneShutter is the position of a Shutter in a Scene.
+type SceneShutter struct {
+	// Name of the Shutter.
+	Name string `json:"name"`
+	// ClosedPercentage is the position the Shutter is moved to.
+	// Ignored, when Position is set.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	ClosedPercentage int `json:"closedPercentage,omitempty"`
+	// Position is the name of a preset position the Shutter is moved to.
+	Position string `json:"position,omitempty"`
+}
+
+// SceneLight is the state of a Light in a Scene.
+type SceneLight struct {
+	// Name of the Light.
+	Name string `json:"name"`
+	On   bool   `json:"on"`
+}
+
+type ScenePhaseTypes string
+
+const (
+	SceneInactive = "Inactive"
+	SceneApplying = "Applying"
+	SceneComplete = "Complete"
+	SceneError    = "Error"
+)
+
+const (
+	// SceneCompleted is True, when all devices of an active Scene reached their state.
+	SceneCompleted = "Complete"
+)
+
+// SceneStatus defines the observed state of Scene
+type SceneStatus struct {
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// AppliedGeneration is the generation of the Scene last applied to its devices.
+	AppliedGeneration int64           `json:"appliedGeneration,omitempty"`
+	Phase             ScenePhaseTypes `json:"phase,omitempty"`
+	// Shutters reports the progress of each Shutter of an active Scene.
+	Shutters []SceneShutterStatus `json:"shutters,omitempty"`
+	// Lights reports the progress of each Light of an active Scene.
+	Lights     []SceneLightStatus `json:"lights,omitempty"`
+	Conditions []Condition        `json:"conditions,omitempty"`
+}
+
+// SceneShutterStatus is the progress of a Shutter in a Scene.
+type SceneShutterStatus struct {
+	Name             string `json:"name"`
+	ClosedPercentage int    `json:"closedPercentage"`
+	TargetPercentage int    `json:"targetPercentage"`
+	// Complete is true, when the Shutter stopped at its target.
+	Complete bool `json:"complete"`
+	// Message explains, why the Shutter is not complete.
+	Message string `json:"message,omitempty"`
+}
+
+// SceneLightStatus is the progress of a Light in a Scene.
+type SceneLightStatus struct {
+	Name string `json:"name"`
+	On   bool   `json:"on"`
+	// Complete is true, when the Light was switched.
+	Complete bool `json:"complete"`
+	// Message explains, why the Light is not complete.
+	Message string `json:"message,omitempty"`
+}
+
+// Scene is the Schema for the scenes API
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Active",type="boolean",JSONPath=".spec.active"
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type Scene struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SceneSpec   `json:"spec,omitempty"`
+	Status SceneStatus `json:"status,omitempty"`
+}
+
+// SceneList contains a list of Scene
+// +kubebuilder:object:root=true
+type SceneList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Scene `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Scene{}, &SceneList{})
+}
@@ -21,14 +21,59 @@ import (
 
 // ShutterSpec defines the desired state of Shutter
 type ShutterSpec struct {
-	ClosedPercentage int `json:"closedPercentage"`
+	// ClosedPercentage is the position the Shutter should move to,
+	// from 0 (fully open) to 100 (fully closed).
+	// Ignored, when Position is set.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	ClosedPercentage int `json:"closedPercentage,omitempty"`
+	// Position is the name of a preset position the Shutter should move to.
+	// It is resolved from a ShutterPreset in the same namespace, a ClusterShutterPreset
+	// or one of the built-in positions "open" and "closed", in this order.
+	Position string `json:"position,omitempty"`
+	// Motion describes how the Shutter physically moves.
+	// Defaults to roughly 9% per second in both directions.
+	Motion *ShutterMotion `json:"motion,omitempty"`
 }
 
+// ShutterMotion describes the physical movement of a Shutter.
+type ShutterMotion struct {
+	// CloseTime is the time a full close takes at full speed.
+	CloseTime metav1.Duration `json:"closeTime"`
+	// OpenTime is the time a full open takes at full speed.
+	// Defaults to CloseTime.
+	OpenTime metav1.Duration `json:"openTime,omitempty"`
+	// Acceleration is the time the motor needs to reach full speed.
+	Acceleration metav1.Duration `json:"acceleration,omitempty"`
+	// DeadTime is the delay between starting the motor and the Shutter starting to move.
+	DeadTime metav1.Duration `json:"deadTime,omitempty"`
+}
+
+// Built-in positions, available without a ShutterPreset or ClusterShutterPreset.
+const (
+	ShutterPositionOpen   = "open"
+	ShutterPositionClosed = "closed"
+)
+
 type ShutterPhaseTypes string
 
 const (
 	ShutterMoving = "Moving"
 	ShutterIdle   = "Idle"
+	ShutterError  = "Error"
+)
+
+const (
+	// ShutterReady is True, when the Shutter is reachable and operating without errors.
+	ShutterReady = "Ready"
+	// ShutterReachable is False, when the Shutter can not be found or reached in the smart home.
+	ShutterReachable = "Reachable"
+	// ShutterInPosition is True, when the Shutter has stopped at its target position.
+	ShutterInPosition = "InPosition"
+	// ShutterDegraded is True, when the Shutter stopped before its target or operations are failing.
+	ShutterDegraded = "Degraded"
+	// ShutterLocked is True, while a SafetyInterlock forces the Shutter to a safe position.
+	ShutterLocked = "Locked"
 )
 
 // ShutterStatus defines the observed state of Shutter
@@ -36,14 +81,28 @@ type ShutterStatus struct {
 	ObservedGeneration int64             `json:"observedGeneration,omitempty"`
 	Phase              ShutterPhaseTypes `json:"phase,omitempty"`
 	ClosedPercentage   int               `json:"closedPercentage"`
+	// Position is the preset position the Shutter is moving to.
+	Position string `json:"position,omitempty"`
+	// TargetPercentage is the position the Shutter is moving to.
+	TargetPercentage int `json:"targetPercentage"`
+	// LockedBy is the name of the SafetyInterlock forcing the Shutter to a safe position.
+	// Changes to the spec are rejected while the Shutter is locked,
+	// ShutterGroups, Scenes, ShutterSchedules and SunTriggers skip locked Shutters.
+	LockedBy string `json:"lockedBy,omitempty"`
+	// LastMovedTime is the last time the position of the Shutter changed.
+	LastMovedTime *metav1.Time `json:"lastMovedTime,omitempty"`
+	Conditions    []Condition  `json:"conditions,omitempty"`
 }
 
 // Shutter is the Schema for the shutters API
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
-// +kubebuilder:printcolumn:name="Target",type="string",JSONPath=".spec.closedPercentage"
+// +kubebuilder:printcolumn:name="Target",type="string",JSONPath=".status.targetPercentage"
+// +kubebuilder:printcolumn:name="Position",type="string",JSONPath=".spec.position"
 // +kubebuilder:printcolumn:name="Current",type="string",JSONPath=".status.closedPercentage"
 // +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Locked",type="string",JSONPath=`.status.conditions[?(@.type=="Locked")].status`
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 type Shutter struct {
 	metav1.TypeMeta   `json:",inline"`
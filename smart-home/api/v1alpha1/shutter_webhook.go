@@ -0,0 +1,119 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+func (r *Shutter) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-smarthome-loodse-io-v1alpha1-shutter,mutating=true,failurePolicy=fail,groups=smarthome.loodse.io,resources=shutters,verbs=create;update,versions=v1alpha1,name=mshutter.kb.io
+
+var _ webhook.Defaulter = &Shutter{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (r *Shutter) Default() {
+	if r.Spec.Motion != nil {
+		r.Spec.Motion.Default()
+	}
+}
+
+// Default sets the OpenTime to the CloseTime, if it is not specified.
+func (m *ShutterMotion) Default() {
+	if m.OpenTime.Duration == 0 {
+		m.OpenTime = m.CloseTime
+	}
+}
+
+// +kubebuilder:webhook:path=/validate-smarthome-loodse-io-v1alpha1-shutter,mutating=false,failurePolicy=fail,groups=smarthome.loodse.io,resources=shutters,verbs=create;update,versions=v1alpha1,name=vshutter.kb.io
+
+var _ webhook.Validator = &Shutter{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *Shutter) ValidateCreate() error {
+	return r.validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *Shutter) ValidateUpdate(old runtime.Object) error {
+	// block changes while a SafetyInterlock forces the Shutter to a safe position
+	if oldShutter, ok := old.(*Shutter); ok && oldShutter.Status.LockedBy != "" &&
+		!reflect.DeepEqual(oldShutter.Spec, r.Spec) {
+		return apierrors.NewForbidden(GroupVersion.WithResource("shutters").GroupResource(), r.Name,
+			fmt.Errorf("shutter is locked by safety interlock %q", oldShutter.Status.LockedBy))
+	}
+	return r.validate()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *Shutter) ValidateDelete() error {
+	return nil
+}
+
+func (r *Shutter) validate() error {
+	allErrs := r.Spec.validate(field.NewPath("spec"))
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("Shutter").GroupKind(), r.Name, allErrs)
+}
+
+func (s *ShutterSpec) validate(fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if s.ClosedPercentage < 0 || s.ClosedPercentage > 100 {
+		allErrs = append(allErrs, field.Invalid(
+			fldPath.Child("closedPercentage"), s.ClosedPercentage, "must be between 0 and 100"))
+	}
+	if s.Motion != nil {
+		allErrs = append(allErrs, s.Motion.validate(fldPath.Child("motion"))...)
+	}
+	return allErrs
+}
+
+// validate mirrors the checks of smarthome.MotionProfile,
+// so physically impossible values are rejected early.
+func (m *ShutterMotion) validate(fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if m.CloseTime.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(
+			fldPath.Child("closeTime"), m.CloseTime.Duration.String(), "must be positive"))
+	}
+	if m.OpenTime.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(
+			fldPath.Child("openTime"), m.OpenTime.Duration.String(), "must be positive"))
+	}
+	if m.Acceleration.Duration < 0 {
+		allErrs = append(allErrs, field.Invalid(
+			fldPath.Child("acceleration"), m.Acceleration.Duration.String(), "must not be negative"))
+	}
+	if m.DeadTime.Duration < 0 {
+		allErrs = append(allErrs, field.Invalid(
+			fldPath.Child("deadTime"), m.DeadTime.Duration.String(), "must not be negative"))
+	}
+	return allErrs
+}
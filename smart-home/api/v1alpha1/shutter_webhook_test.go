@@ -0,0 +1,425 @@
+package v1alpha1
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+)
+
+func TestShutterDefault(t *testing.T) {
+	tests := []struct {
+		name     string
+		motion   *ShutterMotion
+		expected *ShutterMotion
+	}{
+		{
+			name: "no motion",
+		},
+		{
+			name:     "open time defaults to close time",
+			motion:   &ShutterMotion{CloseTime: duration(20 * time.Second)},
+			expected: &ShutterMotion{CloseTime: duration(20 * time.Second), OpenTime: duration(20 * time.Second)},
+		},
+		{
+			name:     "open time is kept",
+			motion:   &ShutterMotion{CloseTime: duration(20 * time.Second), OpenTime: duration(15 * time.Second)},
+			expected: &ShutterMotion{CloseTime: duration(20 * time.Second), OpenTime: duration(15 * time.Second)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			shutter := &Shutter{Spec: ShutterSpec{Motion: test.motion}}
+			shutter.Default()
+
+			if test.expected == nil {
+				if shutter.Spec.Motion != nil {
+					t.Errorf("expected no motion, got %+v", shutter.Spec.Motion)
+				}
+				return
+			}
+			if *shutter.Spec.Motion != *test.expected {
+				t.Errorf("expected motion %+v, got %+v", test.expected, shutter.Spec.Motion)
+			}
+		})
+	}
+}
+
+func TestShutterValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		spec  ShutterSpec
+		valid bool
+	}{
+		{
+			name:  "fully open",
+			spec:  ShutterSpec{ClosedPercentage: 0},
+			valid: true,
+		},
+		{
+			name:  "fully closed",
+			spec:  ShutterSpec{ClosedPercentage: 100},
+			valid: true,
+		},
+		{
+			name: "below 0",
+			spec: ShutterSpec{ClosedPercentage: -1},
+		},
+		{
+			name: "above 100",
+			spec: ShutterSpec{ClosedPercentage: 101},
+		},
+		{
+			name: "valid motion",
+			spec: ShutterSpec{Motion: &ShutterMotion{
+				CloseTime:    duration(10 * time.Second),
+				OpenTime:     duration(12 * time.Second),
+				Acceleration: duration(time.Second),
+			}},
+			valid: true,
+		},
+		{
+			name: "zero close time",
+			spec: ShutterSpec{Motion: &ShutterMotion{OpenTime: duration(10 * time.Second)}},
+		},
+		{
+			name: "negative dead time",
+			spec: ShutterSpec{Motion: &ShutterMotion{
+				CloseTime: duration(10 * time.Second),
+				OpenTime:  duration(10 * time.Second),
+				DeadTime:  duration(-time.Second),
+			}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			shutter := &Shutter{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec:       test.spec,
+			}
+
+			old := &Shutter{ObjectMeta: metav1.ObjectMeta{Name: "test"}}
+			for op, err := range map[string]error{
+				"create": shutter.ValidateCreate(),
+				"update": shutter.ValidateUpdate(old),
+			} {
+				if test.valid && err != nil {
+					t.Errorf("expected no error on %s, got %v", op, err)
+				}
+				if !test.valid && !apierrors.IsInvalid(err) {
+					t.Errorf("expected invalid error on %s, got %v", op, err)
+				}
+			}
+		})
+	}
+}
+
+func TestShutterValidateLocked(t *testing.T) {
+	locked := &Shutter{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec:       ShutterSpec{ClosedPercentage: 20},
+		Status:     ShutterStatus{LockedBy: "storm"},
+	}
+
+	unchanged := locked.DeepCopy()
+	unchanged.Labels = map[string]string{"floor": "ground"}
+	if err := unchanged.ValidateUpdate(locked); err != nil {
+		t.Errorf("expected metadata changes to be allowed, got %v", err)
+	}
+
+	changed := locked.DeepCopy()
+	changed.Spec.ClosedPercentage = 80
+	if err := changed.ValidateUpdate(locked); !apierrors.IsForbidden(err) {
+		t.Errorf("expected forbidden error, got %v", err)
+	}
+
+	unlocked := locked.DeepCopy()
+	unlocked.Status.LockedBy = ""
+	if err := changed.ValidateUpdate(unlocked); err != nil {
+		t.Errorf("expected changes to unlocked shutters to be allowed, got %v", err)
+	}
+}
+
+// TestShutterWebhook runs the webhooks against a real API server.
+// It requires the kubebuilder test assets (etcd and kube-apiserver),
+// see https://book.kubebuilder.io/reference/artifacts.html
+// It is skipped without them, unless KUBEBUILDER_ASSETS is set explicitly.
+func TestShutterWebhook(t *testing.T) {
+	assets, explicit := os.LookupEnv("KUBEBUILDER_ASSETS")
+	if !explicit {
+		assets = "/usr/local/kubebuilder/bin"
+	}
+	if _, err := os.Stat(filepath.Join(assets, "kube-apiserver")); err != nil {
+		if explicit {
+			t.Fatalf("kubebuilder test assets not found: %v", err)
+		}
+		t.Skipf("kubebuilder test assets not found, set KUBEBUILDER_ASSETS to run: %v", err)
+	}
+
+	testEnv := &envtest.Environment{
+		CRDDirectoryPaths: []string{filepath.Join("..", "..", "config", "crd", "bases")},
+	}
+	cfg, err := testEnv.Start()
+	if err != nil {
+		t.Fatalf("starting test environment: %v", err)
+	}
+	defer testEnv.Stop()
+
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = AddToScheme(scheme)
+
+	certDir, caBundle := writeServingCert(t)
+	defer os.RemoveAll(certDir)
+	port := freePort(t)
+
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+		Scheme:             scheme,
+		MetricsBindAddress: "0",
+		Host:               "127.0.0.1",
+		Port:               port,
+		CertDir:            certDir,
+	})
+	if err != nil {
+		t.Fatalf("creating manager: %v", err)
+	}
+	if err := (&Shutter{}).SetupWebhookWithManager(mgr); err != nil {
+		t.Fatalf("setting up webhook: %v", err)
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		if err := mgr.Start(stop); err != nil {
+			t.Errorf("running manager: %v", err)
+		}
+	}()
+	waitForServer(t, port)
+
+	ctx := context.Background()
+	c, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		t.Fatalf("creating client: %v", err)
+	}
+	installWebhooks(t, c, port, caBundle)
+
+	// the CRD schema only validates the closed percentage,
+	// so motion profiles are rejected by the webhook
+	t.Run("rejects negative dead time", func(t *testing.T) {
+		shutter := &Shutter{
+			ObjectMeta: metav1.ObjectMeta{Name: "negative-dead-time", Namespace: "default"},
+			Spec: ShutterSpec{
+				ClosedPercentage: 50,
+				Motion: &ShutterMotion{
+					CloseTime: duration(10 * time.Second),
+					DeadTime:  duration(-time.Second),
+				},
+			},
+		}
+		expectWebhookDenied(t, c.Create(ctx, shutter))
+	})
+
+	t.Run("rejects invalid updates", func(t *testing.T) {
+		shutter := &Shutter{
+			ObjectMeta: metav1.ObjectMeta{Name: "update", Namespace: "default"},
+			Spec:       ShutterSpec{ClosedPercentage: 50},
+		}
+		if err := c.Create(ctx, shutter); err != nil {
+			t.Fatalf("creating shutter: %v", err)
+		}
+		shutter.Spec.Motion = &ShutterMotion{CloseTime: duration(-10 * time.Second)}
+		expectWebhookDenied(t, c.Update(ctx, shutter))
+	})
+
+	t.Run("rejects impossible motion", func(t *testing.T) {
+		shutter := &Shutter{
+			ObjectMeta: metav1.ObjectMeta{Name: "impossible-motion", Namespace: "default"},
+			Spec: ShutterSpec{
+				ClosedPercentage: 50,
+				Motion: &ShutterMotion{
+					CloseTime: duration(-10 * time.Second),
+				},
+			},
+		}
+		expectWebhookDenied(t, c.Create(ctx, shutter))
+	})
+
+	t.Run("defaults open time", func(t *testing.T) {
+		shutter := &Shutter{
+			ObjectMeta: metav1.ObjectMeta{Name: "defaulted", Namespace: "default"},
+			Spec: ShutterSpec{
+				ClosedPercentage: 50,
+				Motion: &ShutterMotion{
+					CloseTime: duration(20 * time.Second),
+				},
+			},
+		}
+		if err := c.Create(ctx, shutter); err != nil {
+			t.Fatalf("creating shutter: %v", err)
+		}
+		if openTime := shutter.Spec.Motion.OpenTime.Duration; openTime != 20*time.Second {
+			t.Errorf("expected open time 20s, got %s", openTime)
+		}
+	})
+}
+
+// expectWebhookDenied checks that the request was denied by the validating webhook,
+// not by the CRD schema.
+func expectWebhookDenied(t *testing.T, err error) {
+	t.Helper()
+	if err == nil || !strings.Contains(err.Error(), "vshutter.kb.io") {
+		t.Errorf("expected the validating webhook to deny the request, got %v", err)
+	}
+}
+
+func duration(d time.Duration) metav1.Duration {
+	return metav1.Duration{Duration: d}
+}
+
+// writeServingCert writes a self-signed serving certificate for 127.0.0.1
+// into a new directory and returns the directory and the PEM encoded certificate.
+func writeServingCert(t *testing.T) (string, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("creating certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshaling key: %v", err)
+	}
+
+	dir, err := ioutil.TempDir("", "shutter-webhook")
+	if err != nil {
+		t.Fatalf("creating cert dir: %v", err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := ioutil.WriteFile(filepath.Join(dir, "tls.crt"), certPEM, 0600); err != nil {
+		t.Fatalf("writing certificate: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "tls.key"), keyPEM, 0600); err != nil {
+		t.Fatalf("writing key: %v", err)
+	}
+	return dir, certPEM
+}
+
+func freePort(t *testing.T) int {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("finding free port: %v", err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func waitForServer(t *testing.T, port int) {
+	t.Helper()
+
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		conn, err := tls.Dial("tcp", addr, &tls.Config{InsecureSkipVerify: true})
+		if err == nil {
+			conn.Close()
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("webhook server not ready: %v", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// installWebhooks registers the Shutter webhooks with the API server,
+// pointing them at the locally running webhook server.
+func installWebhooks(t *testing.T, c client.Client, port int, caBundle []byte) {
+	t.Helper()
+
+	failurePolicy := admissionregistrationv1beta1.Fail
+	rules := []admissionregistrationv1beta1.RuleWithOperations{{
+		Operations: []admissionregistrationv1beta1.OperationType{
+			admissionregistrationv1beta1.Create,
+			admissionregistrationv1beta1.Update,
+		},
+		Rule: admissionregistrationv1beta1.Rule{
+			APIGroups:   []string{GroupVersion.Group},
+			APIVersions: []string{GroupVersion.Version},
+			Resources:   []string{"shutters"},
+		},
+	}}
+	clientConfig := func(path string) admissionregistrationv1beta1.WebhookClientConfig {
+		url := "https://" + net.JoinHostPort("127.0.0.1", strconv.Itoa(port)) + path
+		return admissionregistrationv1beta1.WebhookClientConfig{URL: &url, CABundle: caBundle}
+	}
+
+	ctx := context.Background()
+	mutating := &admissionregistrationv1beta1.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "mutating-webhook-configuration"},
+		Webhooks: []admissionregistrationv1beta1.Webhook{{
+			Name:          "mshutter.kb.io",
+			ClientConfig:  clientConfig("/mutate-smarthome-loodse-io-v1alpha1-shutter"),
+			Rules:         rules,
+			FailurePolicy: &failurePolicy,
+		}},
+	}
+	if err := c.Create(ctx, mutating); err != nil {
+		t.Fatalf("creating mutating webhook configuration: %v", err)
+	}
+	validating := &admissionregistrationv1beta1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "validating-webhook-configuration"},
+		Webhooks: []admissionregistrationv1beta1.Webhook{{
+			Name:          "vshutter.kb.io",
+			ClientConfig:  clientConfig("/validate-smarthome-loodse-io-v1alpha1-shutter"),
+			Rules:         rules,
+			FailurePolicy: &failurePolicy,
+		}},
+	}
+	if err := c.Create(ctx, validating); err != nil {
+		t.Fatalf("creating validating webhook configuration: %v", err)
+	}
+
+	// the API server picks up webhook configurations asynchronously
+	time.Sleep(2 * time.Second)
+}
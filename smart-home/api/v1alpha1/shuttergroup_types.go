@@ -0,0 +1,90 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ShutterGroupSpec defines the desired state of ShutterGroup
+type ShutterGroupSpec struct {
+	// Selector selects the member Shutters in the namespace of the ShutterGroup.
+	Selector metav1.LabelSelector `json:"selector"`
+	// ClosedPercentage is the position the members are moved to,
+	// whenever it changes and when a Shutter joins the group.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	ClosedPercentage int `json:"closedPercentage"`
+}
+
+type ShutterGroupPhaseTypes string
+
+const (
+	ShutterGroupMoving = "Moving"
+	ShutterGroupIdle   = "Idle"
+)
+
+// ShutterGroupStatus defines the observed state of ShutterGroup
+type ShutterGroupStatus struct {
+	ObservedGeneration int64                  `json:"observedGeneration,omitempty"`
+	Phase              ShutterGroupPhaseTypes `json:"phase,omitempty"`
+	// Members are the names of the Shutters in the group.
+	Members []string `json:"members,omitempty"`
+	// PendingMembers are the names of the members, that were locked when the group moved them.
+	// They are moved to the position of the group, once they are unlocked.
+	PendingMembers []string `json:"pendingMembers,omitempty"`
+	// MemberCount is the number of Shutters in the group.
+	MemberCount int `json:"memberCount"`
+	// MovingCount is the number of moving Shutters in the group.
+	MovingCount int `json:"movingCount"`
+	// MinClosedPercentage is the current position of the most open member.
+	MinClosedPercentage int `json:"minClosedPercentage"`
+	// MaxClosedPercentage is the current position of the most closed member.
+	MaxClosedPercentage int `json:"maxClosedPercentage"`
+	// AverageClosedPercentage is the average current position of all members, rounded to a full percent.
+	AverageClosedPercentage int `json:"averageClosedPercentage"`
+}
+
+// ShutterGroup is the Schema for the shuttergroups API
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Target",type="string",JSONPath=".spec.closedPercentage"
+// +kubebuilder:printcolumn:name="Members",type="integer",JSONPath=".status.memberCount"
+// +kubebuilder:printcolumn:name="Moving",type="integer",JSONPath=".status.movingCount"
+// +kubebuilder:printcolumn:name="Min",type="integer",JSONPath=".status.minClosedPercentage"
+// +kubebuilder:printcolumn:name="Avg",type="integer",JSONPath=".status.averageClosedPercentage"
+// +kubebuilder:printcolumn:name="Max",type="integer",JSONPath=".status.maxClosedPercentage"
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type ShutterGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ShutterGroupSpec   `json:"spec,omitempty"`
+	Status ShutterGroupStatus `json:"status,omitempty"`
+}
+
+// ShutterGroupList contains a list of ShutterGroup
+// +kubebuilder:object:root=true
+type ShutterGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ShutterGroup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ShutterGroup{}, &ShutterGroupList{})
+}
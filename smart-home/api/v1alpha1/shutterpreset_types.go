@@ -0,0 +1,54 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ShutterPresetSpec defines the position a named preset stands for.
+type ShutterPresetSpec struct {
+	// ClosedPercentage is the position Shutters using the preset move to,
+	// from 0 (fully open) to 100 (fully closed).
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	ClosedPercentage int `json:"closedPercentage"`
+}
+
+// ShutterPreset is the Schema for the shutterpresets API.
+// It names a position for Shutters in the same namespace,
+// overriding a ClusterShutterPreset of the same name.
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Closed",type="integer",JSONPath=".spec.closedPercentage"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type ShutterPreset struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ShutterPresetSpec `json:"spec,omitempty"`
+}
+
+// ShutterPresetList contains a list of ShutterPreset
+// +kubebuilder:object:root=true
+type ShutterPresetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ShutterPreset `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ShutterPreset{}, &ShutterPresetList{})
+}
@@ -0,0 +1,87 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ShutterScheduleSpec defines the desired state of ShutterSchedule
+type ShutterScheduleSpec struct {
+	// Schedule is a cron expression, e.g. "0 22 * * *" or "0 7 * * 1-5".
+	Schedule string `json:"schedule"`
+	// TimeZone is the IANA time zone the Schedule is evaluated in, e.g. "Europe/Berlin".
+	// Defaults to UTC.
+	// Must not be set, when the Schedule has a "CRON_TZ=" or "TZ=" prefix.
+	TimeZone string `json:"timeZone,omitempty"`
+	// Selector selects the Shutters in the namespace of the ShutterSchedule to move.
+	Selector metav1.LabelSelector `json:"selector"`
+
+	// ClosedPercentage is the position the selected Shutters are moved to.
+	// Ignored, when Position is set.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	ClosedPercentage int `json:"closedPercentage,omitempty"`
+	// Position is the name of a preset position the selected Shutters are moved to.
+	Position string `json:"position,omitempty"`
+}
+
+const (
+	// ShutterScheduleReady is False, when the schedule can not be evaluated or the Shutters can not be moved.
+	ShutterScheduleReady = "Ready"
+)
+
+// ShutterScheduleStatus defines the observed state of ShutterSchedule
+type ShutterScheduleStatus struct {
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// LastScheduleTime is the last time the selected Shutters were moved.
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+	// NextScheduleTime is the next time the selected Shutters will be moved.
+	// Empty, when the Schedule never runs, e.g. "0 0 30 2 *".
+	NextScheduleTime *metav1.Time `json:"nextScheduleTime,omitempty"`
+	// ShutterCount is the number of Shutters moved at the LastScheduleTime.
+	ShutterCount int         `json:"shutterCount"`
+	Conditions   []Condition `json:"conditions,omitempty"`
+}
+
+// ShutterSchedule is the Schema for the shutterschedules API
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Schedule",type="string",JSONPath=".spec.schedule"
+// +kubebuilder:printcolumn:name="Time Zone",type="string",JSONPath=".spec.timeZone"
+// +kubebuilder:printcolumn:name="Last",type="date",JSONPath=".status.lastScheduleTime"
+// +kubebuilder:printcolumn:name="Next",type="string",JSONPath=".status.nextScheduleTime"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type ShutterSchedule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ShutterScheduleSpec   `json:"spec,omitempty"`
+	Status ShutterScheduleStatus `json:"status,omitempty"`
+}
+
+// ShutterScheduleList contains a list of ShutterSchedule
+// +kubebuilder:object:root=true
+type ShutterScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ShutterSchedule `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ShutterSchedule{}, &ShutterScheduleList{})
+}
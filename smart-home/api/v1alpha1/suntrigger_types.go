@@ -0,0 +1,117 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SunEvent is a daily event of the sun.
+// +kubebuilder:validation:Enum=Sunrise;Sunset
+type SunEvent string
+
+const (
+	Sunrise SunEvent = "Sunrise"
+	Sunset  SunEvent = "Sunset"
+)
+
+// SunTriggerSpec defines the desired state of SunTrigger
+type SunTriggerSpec struct {
+	// Event is the sun event triggering the changes.
+	Event SunEvent `json:"event"`
+	// Offset shifts the trigger relative to the Event, e.g. "-30m" for half an hour before sunset.
+	Offset metav1.Duration `json:"offset,omitempty"`
+	// Latitude of the smart home in decimal degrees, positive north of the equator, e.g. "52.52".
+	// +kubebuilder:validation:Pattern=`^-?[0-9]+(\.[0-9]+)?$`
+	Latitude string `json:"latitude"`
+	// Longitude of the smart home in decimal degrees, positive east of Greenwich, e.g. "13.405".
+	// +kubebuilder:validation:Pattern=`^-?[0-9]+(\.[0-9]+)?$`
+	Longitude string `json:"longitude"`
+
+	// Shutters to move, when triggered.
+	Shutters *SunTriggerShutters `json:"shutters,omitempty"`
+	// Lights to switch, when triggered.
+	Lights *SunTriggerLights `json:"lights,omitempty"`
+}
+
+// SunTriggerShutters selects Shutters and the position to move them to.
+type SunTriggerShutters struct {
+	// Selector selects the Shutters in the namespace of the SunTrigger.
+	Selector metav1.LabelSelector `json:"selector"`
+	// ClosedPercentage is the position the selected Shutters are moved to.
+	// Ignored, when Position is set.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	ClosedPercentage int `json:"closedPercentage,omitempty"`
+	// Position is the name of a preset position the selected Shutters are moved to.
+	Position string `json:"position,omitempty"`
+}
+
+// SunTriggerLights selects Lights and whether to switch them on or off.
+type SunTriggerLights struct {
+	// Selector selects the Lights in the namespace of the SunTrigger.
+	Selector metav1.LabelSelector `json:"selector"`
+	On       bool                 `json:"on"`
+}
+
+const (
+	// SunTriggerReady is False, when the devices can not be changed.
+	SunTriggerReady = "Ready"
+)
+
+// SunTriggerStatus defines the observed state of SunTrigger
+type SunTriggerStatus struct {
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// LastTriggerTime is the last time the selected devices were changed.
+	LastTriggerTime *metav1.Time `json:"lastTriggerTime,omitempty"`
+	// NextTriggerTime is the next time the selected devices will be changed.
+	// Empty, when the sun event does not happen within the next year, e.g. close to the poles.
+	NextTriggerTime *metav1.Time `json:"nextTriggerTime,omitempty"`
+	// ShutterCount is the number of Shutters moved at the LastTriggerTime.
+	ShutterCount int `json:"shutterCount"`
+	// LightCount is the number of Lights switched at the LastTriggerTime.
+	LightCount int         `json:"lightCount"`
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// SunTrigger is the Schema for the suntriggers API
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Event",type="string",JSONPath=".spec.event"
+// +kubebuilder:printcolumn:name="Offset",type="string",JSONPath=".spec.offset"
+// +kubebuilder:printcolumn:name="Last",type="date",JSONPath=".status.lastTriggerTime"
+// +kubebuilder:printcolumn:name="Next",type="string",JSONPath=".status.nextTriggerTime"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type SunTrigger struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SunTriggerSpec   `json:"spec,omitempty"`
+	Status SunTriggerStatus `json:"status,omitempty"`
+}
+
+// SunTriggerList contains a list of SunTrigger
+// +kubebuilder:object:root=true
+type SunTriggerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SunTrigger `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SunTrigger{}, &SunTriggerList{})
+}
@@ -23,27 +23,652 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterShutterPreset) DeepCopyInto(out *ClusterShutterPreset) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterShutterPreset.
+func (in *ClusterShutterPreset) DeepCopy() *ClusterShutterPreset {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterShutterPreset)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterShutterPreset) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterShutterPresetList) DeepCopyInto(out *ClusterShutterPresetList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterShutterPreset, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterShutterPresetList.
+func (in *ClusterShutterPresetList) DeepCopy() *ClusterShutterPresetList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterShutterPresetList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterShutterPresetList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Condition) DeepCopyInto(out *Condition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Condition.
+func (in *Condition) DeepCopy() *Condition {
+	if in == nil {
+		return nil
+	}
+	out := new(Condition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Light) DeepCopyInto(out *Light) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Light.
+func (in *Light) DeepCopy() *Light {
+	if in == nil {
+		return nil
+	}
+	out := new(Light)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Light) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LightList) DeepCopyInto(out *LightList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Light, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LightList.
+func (in *LightList) DeepCopy() *LightList {
+	if in == nil {
+		return nil
+	}
+	out := new(LightList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LightList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LightSpec) DeepCopyInto(out *LightSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LightSpec.
+func (in *LightSpec) DeepCopy() *LightSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LightSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LightStatus) DeepCopyInto(out *LightStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LightStatus.
+func (in *LightStatus) DeepCopy() *LightStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LightStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SafetyInterlock) DeepCopyInto(out *SafetyInterlock) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SafetyInterlock.
+func (in *SafetyInterlock) DeepCopy() *SafetyInterlock {
+	if in == nil {
+		return nil
+	}
+	out := new(SafetyInterlock)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SafetyInterlock) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SafetyInterlockList) DeepCopyInto(out *SafetyInterlockList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SafetyInterlock, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SafetyInterlockList.
+func (in *SafetyInterlockList) DeepCopy() *SafetyInterlockList {
+	if in == nil {
+		return nil
+	}
+	out := new(SafetyInterlockList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SafetyInterlockList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SafetyInterlockSpec) DeepCopyInto(out *SafetyInterlockSpec) {
+	*out = *in
+	if in.ReleaseThreshold != nil {
+		in, out := &in.ReleaseThreshold, &out.ReleaseThreshold
+		*out = new(int)
+		**out = **in
+	}
+	in.Selector.DeepCopyInto(&out.Selector)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SafetyInterlockSpec.
+func (in *SafetyInterlockSpec) DeepCopy() *SafetyInterlockSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SafetyInterlockSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SafetyInterlockStatus) DeepCopyInto(out *SafetyInterlockStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SafetyInterlockStatus.
+func (in *SafetyInterlockStatus) DeepCopy() *SafetyInterlockStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SafetyInterlockStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Scene) DeepCopyInto(out *Scene) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Scene.
+func (in *Scene) DeepCopy() *Scene {
+	if in == nil {
+		return nil
+	}
+	out := new(Scene)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Scene) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SceneLight) DeepCopyInto(out *SceneLight) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SceneLight.
+func (in *SceneLight) DeepCopy() *SceneLight {
+	if in == nil {
+		return nil
+	}
+	out := new(SceneLight)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SceneLightStatus) DeepCopyInto(out *SceneLightStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SceneLightStatus.
+func (in *SceneLightStatus) DeepCopy() *SceneLightStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SceneLightStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SceneList) DeepCopyInto(out *SceneList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Scene, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SceneList.
+func (in *SceneList) DeepCopy() *SceneList {
+	if in == nil {
+		return nil
+	}
+	out := new(SceneList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SceneList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SceneShutter) DeepCopyInto(out *SceneShutter) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SceneShutter.
+func (in *SceneShutter) DeepCopy() *SceneShutter {
+	if in == nil {
+		return nil
+	}
+	out := new(SceneShutter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SceneShutterStatus) DeepCopyInto(out *SceneShutterStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SceneShutterStatus.
+func (in *SceneShutterStatus) DeepCopy() *SceneShutterStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SceneShutterStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SceneSpec) DeepCopyInto(out *SceneSpec) {
+	*out = *in
+	if in.Shutters != nil {
+		in, out := &in.Shutters, &out.Shutters
+		*out = make([]SceneShutter, len(*in))
+		copy(*out, *in)
+	}
+	if in.Lights != nil {
+		in, out := &in.Lights, &out.Lights
+		*out = make([]SceneLight, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SceneSpec.
+func (in *SceneSpec) DeepCopy() *SceneSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SceneSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SceneStatus) DeepCopyInto(out *SceneStatus) {
+	*out = *in
+	if in.Shutters != nil {
+		in, out := &in.Shutters, &out.Shutters
+		*out = make([]SceneShutterStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Lights != nil {
+		in, out := &in.Lights, &out.Lights
+		*out = make([]SceneLightStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SceneStatus.
+func (in *SceneStatus) DeepCopy() *SceneStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SceneStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Shutter) DeepCopyInto(out *Shutter) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Shutter.
+func (in *Shutter) DeepCopy() *Shutter {
+	if in == nil {
+		return nil
+	}
+	out := new(Shutter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Shutter) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShutterGroup) DeepCopyInto(out *ShutterGroup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShutterGroup.
+func (in *ShutterGroup) DeepCopy() *ShutterGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(ShutterGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ShutterGroup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShutterGroupList) DeepCopyInto(out *ShutterGroupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ShutterGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShutterGroupList.
+func (in *ShutterGroupList) DeepCopy() *ShutterGroupList {
+	if in == nil {
+		return nil
+	}
+	out := new(ShutterGroupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ShutterGroupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShutterGroupSpec) DeepCopyInto(out *ShutterGroupSpec) {
+	*out = *in
+	in.Selector.DeepCopyInto(&out.Selector)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShutterGroupSpec.
+func (in *ShutterGroupSpec) DeepCopy() *ShutterGroupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ShutterGroupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShutterGroupStatus) DeepCopyInto(out *ShutterGroupStatus) {
+	*out = *in
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PendingMembers != nil {
+		in, out := &in.PendingMembers, &out.PendingMembers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShutterGroupStatus.
+func (in *ShutterGroupStatus) DeepCopy() *ShutterGroupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ShutterGroupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShutterList) DeepCopyInto(out *ShutterList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Shutter, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShutterList.
+func (in *ShutterList) DeepCopy() *ShutterList {
+	if in == nil {
+		return nil
+	}
+	out := new(ShutterList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ShutterList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShutterMotion) DeepCopyInto(out *ShutterMotion) {
+	*out = *in
+	out.CloseTime = in.CloseTime
+	out.OpenTime = in.OpenTime
+	out.Acceleration = in.Acceleration
+	out.DeadTime = in.DeadTime
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShutterMotion.
+func (in *ShutterMotion) DeepCopy() *ShutterMotion {
+	if in == nil {
+		return nil
+	}
+	out := new(ShutterMotion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShutterPreset) DeepCopyInto(out *ShutterPreset) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	out.Spec = in.Spec
-	out.Status = in.Status
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Shutter.
-func (in *Shutter) DeepCopy() *Shutter {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShutterPreset.
+func (in *ShutterPreset) DeepCopy() *ShutterPreset {
 	if in == nil {
 		return nil
 	}
-	out := new(Shutter)
+	out := new(ShutterPreset)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *Shutter) DeepCopyObject() runtime.Object {
+func (in *ShutterPreset) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -51,40 +676,165 @@ func (in *Shutter) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ShutterList) DeepCopyInto(out *ShutterList) {
+func (in *ShutterPresetList) DeepCopyInto(out *ShutterPresetList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	out.ListMeta = in.ListMeta
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]Shutter, len(*in))
+		*out = make([]ShutterPreset, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShutterList.
-func (in *ShutterList) DeepCopy() *ShutterList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShutterPresetList.
+func (in *ShutterPresetList) DeepCopy() *ShutterPresetList {
 	if in == nil {
 		return nil
 	}
-	out := new(ShutterList)
+	out := new(ShutterPresetList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ShutterList) DeepCopyObject() runtime.Object {
+func (in *ShutterPresetList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShutterPresetSpec) DeepCopyInto(out *ShutterPresetSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShutterPresetSpec.
+func (in *ShutterPresetSpec) DeepCopy() *ShutterPresetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ShutterPresetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShutterSchedule) DeepCopyInto(out *ShutterSchedule) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShutterSchedule.
+func (in *ShutterSchedule) DeepCopy() *ShutterSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(ShutterSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ShutterSchedule) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShutterScheduleList) DeepCopyInto(out *ShutterScheduleList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ShutterSchedule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShutterScheduleList.
+func (in *ShutterScheduleList) DeepCopy() *ShutterScheduleList {
+	if in == nil {
+		return nil
+	}
+	out := new(ShutterScheduleList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ShutterScheduleList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShutterScheduleSpec) DeepCopyInto(out *ShutterScheduleSpec) {
+	*out = *in
+	in.Selector.DeepCopyInto(&out.Selector)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShutterScheduleSpec.
+func (in *ShutterScheduleSpec) DeepCopy() *ShutterScheduleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ShutterScheduleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShutterScheduleStatus) DeepCopyInto(out *ShutterScheduleStatus) {
+	*out = *in
+	if in.LastScheduleTime != nil {
+		in, out := &in.LastScheduleTime, &out.LastScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextScheduleTime != nil {
+		in, out := &in.NextScheduleTime, &out.NextScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShutterScheduleStatus.
+func (in *ShutterScheduleStatus) DeepCopy() *ShutterScheduleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ShutterScheduleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ShutterSpec) DeepCopyInto(out *ShutterSpec) {
 	*out = *in
+	if in.Motion != nil {
+		in, out := &in.Motion, &out.Motion
+		*out = new(ShutterMotion)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShutterSpec.
@@ -100,6 +850,17 @@ func (in *ShutterSpec) DeepCopy() *ShutterSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ShutterStatus) DeepCopyInto(out *ShutterStatus) {
 	*out = *in
+	if in.LastMovedTime != nil {
+		in, out := &in.LastMovedTime, &out.LastMovedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShutterStatus.
@@ -111,3 +872,150 @@ func (in *ShutterStatus) DeepCopy() *ShutterStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SunTrigger) DeepCopyInto(out *SunTrigger) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SunTrigger.
+func (in *SunTrigger) DeepCopy() *SunTrigger {
+	if in == nil {
+		return nil
+	}
+	out := new(SunTrigger)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SunTrigger) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SunTriggerLights) DeepCopyInto(out *SunTriggerLights) {
+	*out = *in
+	in.Selector.DeepCopyInto(&out.Selector)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SunTriggerLights.
+func (in *SunTriggerLights) DeepCopy() *SunTriggerLights {
+	if in == nil {
+		return nil
+	}
+	out := new(SunTriggerLights)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SunTriggerList) DeepCopyInto(out *SunTriggerList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SunTrigger, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SunTriggerList.
+func (in *SunTriggerList) DeepCopy() *SunTriggerList {
+	if in == nil {
+		return nil
+	}
+	out := new(SunTriggerList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SunTriggerList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SunTriggerShutters) DeepCopyInto(out *SunTriggerShutters) {
+	*out = *in
+	in.Selector.DeepCopyInto(&out.Selector)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SunTriggerShutters.
+func (in *SunTriggerShutters) DeepCopy() *SunTriggerShutters {
+	if in == nil {
+		return nil
+	}
+	out := new(SunTriggerShutters)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SunTriggerSpec) DeepCopyInto(out *SunTriggerSpec) {
+	*out = *in
+	out.Offset = in.Offset
+	if in.Shutters != nil {
+		in, out := &in.Shutters, &out.Shutters
+		*out = new(SunTriggerShutters)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Lights != nil {
+		in, out := &in.Lights, &out.Lights
+		*out = new(SunTriggerLights)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SunTriggerSpec.
+func (in *SunTriggerSpec) DeepCopy() *SunTriggerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SunTriggerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SunTriggerStatus) DeepCopyInto(out *SunTriggerStatus) {
+	*out = *in
+	if in.LastTriggerTime != nil {
+		in, out := &in.LastTriggerTime, &out.LastTriggerTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextTriggerTime != nil {
+		in, out := &in.NextTriggerTime, &out.NextTriggerTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SunTriggerStatus.
+func (in *SunTriggerStatus) DeepCopy() *SunTriggerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SunTriggerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
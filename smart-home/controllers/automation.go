@@ -0,0 +1,153 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	smarthomev1alpha1 "github.com/loodse/godays-2020-k8s-workshop/smart-home/api/v1alpha1"
+)
+
+// lastRun returns the latest scheduled time after since, which is not after now.
+// Runs missed in between, e.g. while the controller was down, are skipped,
+// as only the latest state matters for a device.
+func lastRun(schedule cron.Schedule, since, now time.Time) (time.Time, bool) {
+	var (
+		last  time.Time
+		found bool
+	)
+	for t := schedule.Next(since); !t.IsZero() && !t.After(now); t = schedule.Next(t) {
+		last, found = t, true
+	}
+	return last, found
+}
+
+// reasonShuttersLocked is the reason of the Events recorded for Shutters skipped,
+// because they are locked by a SafetyInterlock.
+const reasonShuttersLocked = "ShuttersLocked"
+
+// shutterLockedError is returned for Shutters locked by a SafetyInterlock,
+// as the webhook rejects changes to their spec.
+type shutterLockedError string
+
+func (e shutterLockedError) Error() string {
+	return string(e)
+}
+
+// moveShutters patches the selected Shutters in the namespace to the given position
+// and returns the number of patched Shutters and the names of the locked Shutters skipped.
+func moveShutters(
+	ctx context.Context, c client.Client, namespace string, selector *metav1.LabelSelector,
+	position string, closedPercentage int,
+) (int, []string, error) {
+	shutters := &smarthomev1alpha1.ShutterList{}
+	if err := listSelected(ctx, c, shutters, namespace, selector); err != nil {
+		return 0, nil, fmt.Errorf("listing shutters: %w", err)
+	}
+
+	var locked []string
+	for i := range shutters.Items {
+		shutter := &shutters.Items[i]
+		err := moveShutter(ctx, c, shutter, position, closedPercentage)
+		var lockedErr shutterLockedError
+		switch {
+		case errors.As(err, &lockedErr):
+			locked = append(locked, shutter.Name)
+		case err != nil:
+			return 0, nil, err
+		}
+	}
+	return len(shutters.Items) - len(locked), locked, nil
+}
+
+// moveShutter patches the Shutter to the given position, unless it is already set.
+// Locked Shutters are not patched, a shutterLockedError is returned instead.
+func moveShutter(
+	ctx context.Context, c client.Client, shutter *smarthomev1alpha1.Shutter,
+	position string, closedPercentage int,
+) error {
+	if shutter.Spec.Position == position && (position != "" || shutter.Spec.ClosedPercentage == closedPercentage) {
+		return nil
+	}
+	if shutter.Status.LockedBy != "" {
+		return shutterLockedError(fmt.Sprintf("shutter %q is locked by safety interlock %q",
+			shutter.Name, shutter.Status.LockedBy))
+	}
+
+	patch := client.MergeFrom(shutter.DeepCopy())
+	shutter.Spec.Position = position
+	if position == "" {
+		shutter.Spec.ClosedPercentage = closedPercentage
+	}
+	if err := c.Patch(ctx, shutter, patch); err != nil {
+		return fmt.Errorf("patching shutter %q: %w", shutter.Name, err)
+	}
+	return nil
+}
+
+// recordShuttersLocked records an Event, when locked Shutters were skipped.
+func recordShuttersLocked(recorder record.EventRecorder, obj runtime.Object, locked []string) {
+	if len(locked) == 0 {
+		return
+	}
+	recorder.Eventf(obj, corev1.EventTypeWarning, reasonShuttersLocked,
+		"Skipped shutters locked by a safety interlock: %s", strings.Join(locked, ", "))
+}
+
+// switchLights patches the selected Lights in the namespace to be on or off
+// and returns the number of patched Lights.
+func switchLights(
+	ctx context.Context, c client.Client, namespace string, selector *metav1.LabelSelector, on bool,
+) (int, error) {
+	lights := &smarthomev1alpha1.LightList{}
+	if err := listSelected(ctx, c, lights, namespace, selector); err != nil {
+		return 0, fmt.Errorf("listing lights: %w", err)
+	}
+
+	for i := range lights.Items {
+		light := &lights.Items[i]
+		if light.Spec.On == on {
+			continue
+		}
+		patch := client.MergeFrom(light.DeepCopy())
+		light.Spec.On = on
+		if err := c.Patch(ctx, light, patch); err != nil {
+			return 0, fmt.Errorf("patching light %q: %w", light.Name, err)
+		}
+	}
+	return len(lights.Items), nil
+}
+
+func listSelected(
+	ctx context.Context, c client.Client, list runtime.Object, namespace string, selector *metav1.LabelSelector,
+) error {
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return fmt.Errorf("parsing selector: %w", err)
+	}
+	return c.List(ctx, list, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: s})
+}
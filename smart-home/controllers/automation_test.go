@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	smarthomev1alpha1 "github.com/loodse/godays-2020-k8s-workshop/smart-home/api/v1alpha1"
+)
+
+func TestMoveShuttersSkipsLocked(t *testing.T) {
+	ctx := context.Background()
+	shutter := func(name, lockedBy string) *smarthomev1alpha1.Shutter {
+		return &smarthomev1alpha1.Shutter{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"floor": "1"}},
+			Spec:       smarthomev1alpha1.ShutterSpec{ClosedPercentage: 10},
+			Status:     smarthomev1alpha1.ShutterStatus{LockedBy: lockedBy},
+		}
+	}
+	c := fake.NewFakeClientWithScheme(testScheme(t),
+		shutter("bedroom", ""), shutter("kitchen", "storm"), shutter("terrace", "storm"))
+
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"floor": "1"}}
+	count, locked, err := moveShutters(ctx, c, "default", selector, "", 80)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 shutter moved, got %d", count)
+	}
+	if expected := []string{"kitchen", "terrace"}; !reflect.DeepEqual(locked, expected) {
+		t.Errorf("expected locked shutters %v, got %v", expected, locked)
+	}
+
+	for name, expected := range map[string]int{"bedroom": 80, "kitchen": 10, "terrace": 10} {
+		got := &smarthomev1alpha1.Shutter{}
+		if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Spec.ClosedPercentage != expected {
+			t.Errorf("expected shutter %s at %d%%, got %d%%", name, expected, got.Spec.ClosedPercentage)
+		}
+	}
+
+	recorder := record.NewFakeRecorder(1)
+	recordShuttersLocked(recorder, &smarthomev1alpha1.ShutterSchedule{}, locked)
+	expectedEvent := "Warning ShuttersLocked Skipped shutters locked by a safety interlock: kitchen, terrace"
+	if event := <-recorder.Events; event != expectedEvent {
+		t.Errorf("expected event %q, got %q", expectedEvent, event)
+	}
+}
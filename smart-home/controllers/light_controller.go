@@ -0,0 +1,119 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	smarthomev1alpha1 "github.com/loodse/godays-2020-k8s-workshop/smart-home/api/v1alpha1"
+	"github.com/loodse/godays-2020-k8s-workshop/smart-home/pkg/smarthome"
+)
+
+// LightReconciler reconciles a Light object
+type LightReconciler struct {
+	client.Client
+	Log             logr.Logger
+	SmartHomeClient *smarthome.Client
+}
+
+// +kubebuilder:rbac:groups=smarthome.loodse.io,resources=lights,verbs=get;list;watch;create;update
+// +kubebuilder:rbac:groups=smarthome.loodse.io,resources=lights/status,verbs=get;update;patch
+
+func (r *LightReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	var (
+		ctx    = context.Background()
+		result ctrl.Result
+		_      = r.Log.WithValues("light", req.NamespacedName)
+	)
+
+	// Load Light instance from cache.
+	light := &smarthomev1alpha1.Light{}
+	if err := r.Get(ctx, req.NamespacedName, light); err != nil {
+		return result, client.IgnoreNotFound(err)
+	}
+
+	state, err := r.updateDevice(ctx, req.NamespacedName.String(), light)
+	var notFound smarthome.NotFoundError
+	if errors.As(err, &notFound) {
+		// The Light is not (yet) part of the smart home inventory.
+		light.Status.ObservedGeneration = light.Generation
+		smarthomev1alpha1.SetCondition(&light.Status.Conditions, smarthomev1alpha1.Condition{
+			Type:               smarthomev1alpha1.LightReachable,
+			Status:             smarthomev1alpha1.ConditionFalse,
+			ObservedGeneration: light.Generation,
+			Reason:             "NotFound",
+			Message:            notFound.Error(),
+		})
+		if err := r.Client.Status().Update(ctx, light); err != nil {
+			return result, fmt.Errorf("updating light status: %w", err)
+		}
+
+		// Check again later, as the Light might be registered in the meantime.
+		result.RequeueAfter = notFoundRequeueInterval
+		return result, nil
+	}
+	if err != nil {
+		return result, err
+	}
+
+	// Update the Status of the light, to tell the rest of the system what is going on.
+	light.Status.ObservedGeneration = light.Generation
+	light.Status.On = state.On
+	if state.On {
+		light.Status.Phase = smarthomev1alpha1.LightOn
+	} else {
+		light.Status.Phase = smarthomev1alpha1.LightOff
+	}
+	smarthomev1alpha1.SetCondition(&light.Status.Conditions, smarthomev1alpha1.Condition{
+		Type:               smarthomev1alpha1.LightReachable,
+		Status:             smarthomev1alpha1.ConditionTrue,
+		ObservedGeneration: light.Generation,
+		Reason:             "Found",
+	})
+	if err := r.Client.Status().Update(ctx, light); err != nil {
+		return result, fmt.Errorf("updating light status: %w", err)
+	}
+
+	return result, nil
+}
+
+// updateDevice switches the smart home light as specified and returns its current state.
+func (r *LightReconciler) updateDevice(
+	ctx context.Context, name string, light *smarthomev1alpha1.Light,
+) (smarthome.Light, error) {
+	// Switching is idempotent, so we can just tell the light what we want.
+	if err := r.SmartHomeClient.Lights().Switch(ctx, name, light.Spec.On); err != nil {
+		return smarthome.Light{}, fmt.Errorf("switching light: %w", err)
+	}
+
+	state, err := r.SmartHomeClient.Lights().Get(ctx, name)
+	if err != nil {
+		return smarthome.Light{}, fmt.Errorf("checking light state: %w", err)
+	}
+	return state, nil
+}
+
+func (r *LightReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&smarthomev1alpha1.Light{}).
+		Complete(r)
+}
@@ -0,0 +1,174 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	smarthomev1alpha1 "github.com/loodse/godays-2020-k8s-workshop/smart-home/api/v1alpha1"
+	"github.com/loodse/godays-2020-k8s-workshop/smart-home/pkg/smarthome"
+)
+
+// defaultSensorPollInterval is how often sensors are read, unless configured otherwise.
+const defaultSensorPollInterval = 5 * time.Second
+
+// Reasons of the Events recorded by the SafetyInterlockReconciler.
+const (
+	reasonActivated = "Activated"
+	reasonReleased  = "Released"
+)
+
+// SafetyInterlockReconciler reconciles a SafetyInterlock object
+type SafetyInterlockReconciler struct {
+	client.Client
+	Log             logr.Logger
+	Recorder        record.EventRecorder
+	SmartHomeClient *smarthome.Client
+	// PollInterval is how often the sensors are read, defaults to 5s.
+	PollInterval time.Duration
+}
+
+// +kubebuilder:rbac:groups=smarthome.loodse.io,resources=safetyinterlocks,verbs=get;list;watch;create;update
+// +kubebuilder:rbac:groups=smarthome.loodse.io,resources=safetyinterlocks/status,verbs=get;update;patch
+
+func (r *SafetyInterlockReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	var (
+		ctx    = context.Background()
+		result = ctrl.Result{RequeueAfter: r.PollInterval}
+		_      = r.Log.WithValues("safetyinterlock", req.NamespacedName)
+	)
+
+	// Load SafetyInterlock instance from cache.
+	interlock := &smarthomev1alpha1.SafetyInterlock{}
+	if err := r.Get(ctx, req.NamespacedName, interlock); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	interlock.Status.ObservedGeneration = interlock.Generation
+	if err := validateSafetyInterlock(interlock.Spec); err != nil {
+		// Retrying will not help, we have to wait for the SafetyInterlock spec to change.
+		// Keep the interlock as it is, as we can not tell whether it is safe to release it.
+		r.Recorder.Event(interlock, corev1.EventTypeWarning, reasonInvalidSpec, err.Error())
+		setSafetyInterlockCondition(interlock, smarthomev1alpha1.SafetyInterlockReady,
+			smarthomev1alpha1.ConditionFalse, reasonInvalidSpec, err.Error())
+		if err := r.Client.Status().Update(ctx, interlock); err != nil {
+			return ctrl.Result{}, fmt.Errorf("updating safety interlock status: %w", err)
+		}
+		return ctrl.Result{}, nil
+	}
+	setSafetyInterlockCondition(interlock, smarthomev1alpha1.SafetyInterlockReady,
+		smarthomev1alpha1.ConditionTrue, "Valid", "")
+
+	sensor, err := r.SmartHomeClient.Sensors().Get(ctx, interlock.Spec.Sensor)
+	if err != nil {
+		// Keep the interlock as it is, as we can not tell whether it is safe to release it.
+		setSafetyInterlockCondition(interlock, smarthomev1alpha1.SafetyInterlockSensorReachable,
+			smarthomev1alpha1.ConditionFalse, "SensorError", err.Error())
+		if err := r.Client.Status().Update(ctx, interlock); err != nil {
+			return ctrl.Result{}, fmt.Errorf("updating safety interlock status: %w", err)
+		}
+
+		var notFound smarthome.NotFoundError
+		if errors.As(err, &notFound) {
+			// Check again later, as the sensor might be registered in the meantime.
+			result.RequeueAfter = notFoundRequeueInterval
+			return result, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("reading sensor: %w", err)
+	}
+	setSafetyInterlockCondition(interlock, smarthomev1alpha1.SafetyInterlockSensorReachable,
+		smarthomev1alpha1.ConditionTrue, "Found", "")
+
+	// Activate at the threshold and release below the release threshold,
+	// so readings close to the threshold don't toggle the interlock.
+	threshold := float64(interlock.Spec.Threshold)
+	releaseThreshold := threshold
+	if t := interlock.Spec.ReleaseThreshold; t != nil {
+		releaseThreshold = float64(*t)
+	}
+	value := strconv.FormatFloat(sensor.Value, 'f', -1, 64)
+	interlock.Status.SensorValue = value
+	switch {
+	case !interlock.Status.Active && sensor.Value >= threshold:
+		interlock.Status.Active = true
+		r.Recorder.Eventf(interlock, corev1.EventTypeWarning, reasonActivated,
+			"Sensor %q reads %s, locking shutters at %d%%", interlock.Spec.Sensor, value, interlock.Spec.SafeClosedPercentage)
+	case interlock.Status.Active && sensor.Value < releaseThreshold:
+		interlock.Status.Active = false
+		r.Recorder.Eventf(interlock, corev1.EventTypeNormal, reasonReleased,
+			"Sensor %q reads %s, releasing shutters", interlock.Spec.Sensor, value)
+	}
+
+	if interlock.Status.Active {
+		setSafetyInterlockCondition(interlock, smarthomev1alpha1.SafetyInterlockActive,
+			smarthomev1alpha1.ConditionTrue, "ThresholdReached",
+			fmt.Sprintf("sensor %q reads %s, threshold is %d", interlock.Spec.Sensor, value, interlock.Spec.Threshold))
+	} else {
+		setSafetyInterlockCondition(interlock, smarthomev1alpha1.SafetyInterlockActive,
+			smarthomev1alpha1.ConditionFalse, "BelowThreshold", "")
+	}
+	if err := r.Client.Status().Update(ctx, interlock); err != nil {
+		return ctrl.Result{}, fmt.Errorf("updating safety interlock status: %w", err)
+	}
+
+	// Sensors can not be watched, so we poll them.
+	return result, nil
+}
+
+// validateSafetyInterlock checks the parts of the spec the CRD can not validate.
+func validateSafetyInterlock(spec smarthomev1alpha1.SafetyInterlockSpec) error {
+	if t := spec.ReleaseThreshold; t != nil && *t > spec.Threshold {
+		// The interlock would be released right after activating it, and activated again.
+		return fmt.Errorf("release threshold %d is above threshold %d", *t, spec.Threshold)
+	}
+	return nil
+}
+
+func setSafetyInterlockCondition(
+	interlock *smarthomev1alpha1.SafetyInterlock, conditionType string,
+	status smarthomev1alpha1.ConditionStatus, reason, message string,
+) {
+	smarthomev1alpha1.SetCondition(&interlock.Status.Conditions, smarthomev1alpha1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: interlock.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+func (r *SafetyInterlockReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Recorder == nil {
+		r.Recorder = mgr.GetEventRecorderFor("safetyinterlock-controller")
+	}
+	if r.PollInterval == 0 {
+		r.PollInterval = defaultSensorPollInterval
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&smarthomev1alpha1.SafetyInterlock{}).
+		Complete(r)
+}
@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	smarthomev1alpha1 "github.com/loodse/godays-2020-k8s-workshop/smart-home/api/v1alpha1"
+)
+
+func TestSafetyInterlockReleaseThresholdAboveThreshold(t *testing.T) {
+	releaseThreshold := 60
+	interlock := &smarthomev1alpha1.SafetyInterlock{
+		ObjectMeta: metav1.ObjectMeta{Name: "storm", Namespace: "default", Generation: 1},
+		Spec: smarthomev1alpha1.SafetyInterlockSpec{
+			Sensor:           "wind-speed",
+			Threshold:        50,
+			ReleaseThreshold: &releaseThreshold,
+		},
+	}
+	c := fake.NewFakeClientWithScheme(testScheme(t), interlock)
+	recorder := record.NewFakeRecorder(10)
+	// the sensor must not be read, so there is no smart home client
+	r := &SafetyInterlockReconciler{
+		Client:       c,
+		Log:          ctrl.Log,
+		Recorder:     recorder,
+		PollInterval: defaultSensorPollInterval,
+	}
+
+	key := types.NamespacedName{Namespace: "default", Name: "storm"}
+	result, err := r.Reconcile(ctrl.Request{NamespacedName: key})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != (ctrl.Result{}) {
+		t.Errorf("expected no requeue, got %+v", result)
+	}
+	expectedEvent := "Warning InvalidSpec release threshold 60 is above threshold 50"
+	if event := <-recorder.Events; event != expectedEvent {
+		t.Errorf("expected event %q, got %q", expectedEvent, event)
+	}
+
+	got := &smarthomev1alpha1.SafetyInterlock{}
+	if err := c.Get(context.Background(), key, got); err != nil {
+		t.Fatal(err)
+	}
+	ready := smarthomev1alpha1.FindCondition(got.Status.Conditions, smarthomev1alpha1.SafetyInterlockReady)
+	if ready == nil || ready.Status != smarthomev1alpha1.ConditionFalse || ready.Reason != reasonInvalidSpec {
+		t.Errorf("expected the interlock not to be ready, got %+v", ready)
+	}
+	if got.Status.Active {
+		t.Error("expected the interlock not to be active")
+	}
+}
@@ -0,0 +1,346 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	smarthomev1alpha1 "github.com/loodse/godays-2020-k8s-workshop/smart-home/api/v1alpha1"
+)
+
+// Fields indexing Scenes by the names of their devices.
+const (
+	sceneShutterField = "spec.shutters.name"
+	sceneLightField   = "spec.lights.name"
+)
+
+// Reasons of the Events recorded by the SceneReconciler.
+const (
+	reasonApplied   = "Applied"
+	reasonCompleted = "Completed"
+)
+
+// SceneReconciler reconciles a Scene object.
+// It applies Scenes by patching the Shutters and Lights,
+// their reconcilers change the devices through the smarthome.Client.
+type SceneReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=smarthome.loodse.io,resources=scenes,verbs=get;list;watch;create;update
+// +kubebuilder:rbac:groups=smarthome.loodse.io,resources=scenes/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=smarthome.loodse.io,resources=shutters,verbs=get;list;watch;patch
+// +kubebuilder:rbac:groups=smarthome.loodse.io,resources=lights,verbs=get;list;watch;patch
+// +kubebuilder:rbac:groups=smarthome.loodse.io,resources=shutterpresets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=smarthome.loodse.io,resources=clustershutterpresets,verbs=get;list;watch
+
+func (r *SceneReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	var (
+		ctx    = context.Background()
+		result ctrl.Result
+		_      = r.Log.WithValues("scene", req.NamespacedName)
+	)
+
+	// Load Scene instance from cache.
+	scene := &smarthomev1alpha1.Scene{}
+	if err := r.Get(ctx, req.NamespacedName, scene); err != nil {
+		return result, client.IgnoreNotFound(err)
+	}
+
+	previousPhase := scene.Status.Phase
+	scene.Status.ObservedGeneration = scene.Generation
+	if !scene.Spec.Active {
+		scene.Status.Phase = smarthomev1alpha1.SceneInactive
+		scene.Status.Shutters = nil
+		scene.Status.Lights = nil
+		setSceneCompleted(scene, smarthomev1alpha1.ConditionFalse, "Inactive", "")
+		if err := r.Client.Status().Update(ctx, scene); err != nil {
+			return result, fmt.Errorf("updating scene status: %w", err)
+		}
+		return result, nil
+	}
+
+	// Apply the Scene once per generation, so devices may be changed again afterwards.
+	// Locked Shutters keep the Scene from being applied, it is applied again once they are unlocked.
+	var (
+		missing bool
+		locked  []string
+	)
+	if scene.Status.AppliedGeneration != scene.Generation {
+		applied, lockedShutters, err := r.apply(ctx, scene)
+		if err != nil {
+			return result, err
+		}
+		missing, locked = !applied, lockedShutters
+		if applied && len(locked) == 0 {
+			r.Recorder.Event(scene, corev1.EventTypeNormal, reasonApplied, "Applied scene to all devices")
+			scene.Status.AppliedGeneration = scene.Generation
+		}
+	}
+
+	// Report the progress of every device.
+	complete, err := r.progress(ctx, scene)
+	if err != nil {
+		return result, err
+	}
+	switch {
+	case missing:
+		scene.Status.Phase = smarthomev1alpha1.SceneError
+		setSceneCompleted(scene, smarthomev1alpha1.ConditionFalse, "DeviceNotFound",
+			"not all devices of the scene exist")
+	case len(locked) > 0:
+		scene.Status.Phase = smarthomev1alpha1.SceneApplying
+		c := smarthomev1alpha1.FindCondition(scene.Status.Conditions, smarthomev1alpha1.SceneCompleted)
+		if c == nil || c.Reason != reasonShuttersLocked {
+			recordShuttersLocked(r.Recorder, scene, locked)
+		}
+		setSceneCompleted(scene, smarthomev1alpha1.ConditionFalse, reasonShuttersLocked,
+			fmt.Sprintf("waiting for shutters locked by a safety interlock: %s", strings.Join(locked, ", ")))
+	case complete:
+		scene.Status.Phase = smarthomev1alpha1.SceneComplete
+		setSceneCompleted(scene, smarthomev1alpha1.ConditionTrue, "Complete", "")
+		if previousPhase != smarthomev1alpha1.SceneComplete {
+			r.Recorder.Event(scene, corev1.EventTypeNormal, reasonCompleted, "All devices reached their state")
+		}
+	default:
+		scene.Status.Phase = smarthomev1alpha1.SceneApplying
+		setSceneCompleted(scene, smarthomev1alpha1.ConditionFalse, "Applying", "")
+		// No need to requeue, we are notified whenever one of the devices changes.
+	}
+	if err := r.Client.Status().Update(ctx, scene); err != nil {
+		return result, fmt.Errorf("updating scene status: %w", err)
+	}
+	return result, nil
+}
+
+// apply patches all devices of the Scene and reports, whether all of them exist.
+// Locked Shutters are skipped and returned, their progress reports the lock.
+func (r *SceneReconciler) apply(ctx context.Context, scene *smarthomev1alpha1.Scene) (bool, []string, error) {
+	applied := true
+	var locked []string
+	for _, s := range scene.Spec.Shutters {
+		shutter := &smarthomev1alpha1.Shutter{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: scene.Namespace, Name: s.Name}, shutter); err != nil {
+			if apierrors.IsNotFound(err) {
+				applied = false
+				continue
+			}
+			return false, nil, fmt.Errorf("getting shutter %q: %w", s.Name, err)
+		}
+
+		err := moveShutter(ctx, r.Client, shutter, s.Position, s.ClosedPercentage)
+		var lockedErr shutterLockedError
+		switch {
+		case errors.As(err, &lockedErr):
+			locked = append(locked, shutter.Name)
+		case err != nil:
+			return false, nil, err
+		}
+	}
+
+	for _, l := range scene.Spec.Lights {
+		light := &smarthomev1alpha1.Light{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: scene.Namespace, Name: l.Name}, light); err != nil {
+			if apierrors.IsNotFound(err) {
+				applied = false
+				continue
+			}
+			return false, nil, fmt.Errorf("getting light %q: %w", l.Name, err)
+		}
+
+		patch := client.MergeFrom(light.DeepCopy())
+		light.Spec.On = l.On
+		if err := r.Patch(ctx, light, patch); err != nil {
+			return false, nil, fmt.Errorf("patching light %q: %w", l.Name, err)
+		}
+	}
+	return applied, locked, nil
+}
+
+// progress reports the state of every device of the Scene in its status
+// and returns, whether all of them reached the state of the Scene.
+// A Shutter is complete, when its target is the position of the Scene and it reached its target.
+// Right after applying the Scene, the cache may still show the Shutter reconciled for its old spec.
+func (r *SceneReconciler) progress(ctx context.Context, scene *smarthomev1alpha1.Scene) (bool, error) {
+	complete := true
+
+	scene.Status.Shutters = make([]smarthomev1alpha1.SceneShutterStatus, len(scene.Spec.Shutters))
+	for i, s := range scene.Spec.Shutters {
+		status := &scene.Status.Shutters[i]
+		status.Name = s.Name
+
+		shutter := &smarthomev1alpha1.Shutter{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: scene.Namespace, Name: s.Name}, shutter); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return false, fmt.Errorf("getting shutter %q: %w", s.Name, err)
+			}
+			status.Message = "shutter not found"
+			complete = false
+			continue
+		}
+
+		status.ClosedPercentage = shutter.Status.ClosedPercentage
+		status.TargetPercentage = shutter.Status.TargetPercentage
+		closedPercentage, err := resolveShutterPosition(ctx, r.Client, scene.Namespace, s.Position, s.ClosedPercentage)
+		var unknownPositionErr unknownPositionError
+		switch {
+		case errors.As(err, &unknownPositionErr):
+			status.Message = err.Error()
+		case err != nil:
+			return false, fmt.Errorf("resolving position of shutter %q: %w", s.Name, err)
+		case shutter.Status.ObservedGeneration != shutter.Generation:
+			status.Message = "waiting for the shutter to be reconciled"
+		case shutter.Status.Phase == smarthomev1alpha1.ShutterError:
+			status.Message = "shutter failed"
+			if c := smarthomev1alpha1.FindCondition(shutter.Status.Conditions, smarthomev1alpha1.ShutterReady); c != nil {
+				status.Message = c.Message
+			}
+		case shutter.Status.LockedBy != "":
+			status.Message = fmt.Sprintf("locked by %s", shutter.Status.LockedBy)
+		case shutter.Status.TargetPercentage != closedPercentage:
+			status.Message = fmt.Sprintf("targets %d%% instead of %d%%", shutter.Status.TargetPercentage, closedPercentage)
+		case shutter.Status.ClosedPercentage != shutter.Status.TargetPercentage:
+			status.Message = fmt.Sprintf("at %d%% of %d%%", shutter.Status.ClosedPercentage, shutter.Status.TargetPercentage)
+		default:
+			status.Complete = true
+		}
+		complete = complete && status.Complete
+	}
+
+	scene.Status.Lights = make([]smarthomev1alpha1.SceneLightStatus, len(scene.Spec.Lights))
+	for i, l := range scene.Spec.Lights {
+		status := &scene.Status.Lights[i]
+		status.Name = l.Name
+
+		light := &smarthomev1alpha1.Light{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: scene.Namespace, Name: l.Name}, light); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return false, fmt.Errorf("getting light %q: %w", l.Name, err)
+			}
+			status.Message = "light not found"
+			complete = false
+			continue
+		}
+
+		status.On = light.Status.On
+		switch {
+		case light.Status.ObservedGeneration != light.Generation:
+			status.Message = "waiting for the light to be reconciled"
+		case light.Status.On != l.On:
+			status.Message = "light not switched"
+		default:
+			status.Complete = true
+		}
+		complete = complete && status.Complete
+	}
+	return complete, nil
+}
+
+func setSceneCompleted(
+	scene *smarthomev1alpha1.Scene,
+	status smarthomev1alpha1.ConditionStatus, reason, message string,
+) {
+	smarthomev1alpha1.SetCondition(&scene.Status.Conditions, smarthomev1alpha1.Condition{
+		Type:               smarthomev1alpha1.SceneCompleted,
+		Status:             status,
+		ObservedGeneration: scene.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+func indexSceneShutters(obj runtime.Object) []string {
+	scene := obj.(*smarthomev1alpha1.Scene)
+	names := make([]string, len(scene.Spec.Shutters))
+	for i, s := range scene.Spec.Shutters {
+		names[i] = s.Name
+	}
+	return names
+}
+
+func indexSceneLights(obj runtime.Object) []string {
+	scene := obj.(*smarthomev1alpha1.Scene)
+	names := make([]string, len(scene.Spec.Lights))
+	for i, l := range scene.Spec.Lights {
+		names[i] = l.Name
+	}
+	return names
+}
+
+// scenesForDevice returns a mapper from a device to the active Scenes listing it in the given field.
+func (r *SceneReconciler) scenesForDevice(field string) handler.ToRequestsFunc {
+	return func(obj handler.MapObject) []ctrl.Request {
+		scenes := &smarthomev1alpha1.SceneList{}
+		if err := r.List(context.Background(), scenes,
+			client.InNamespace(obj.Meta.GetNamespace()),
+			client.MatchingField(field, obj.Meta.GetName()),
+		); err != nil {
+			r.Log.Error(err, "listing scenes for device", "device", obj.Meta.GetName())
+			return nil
+		}
+
+		var requests []ctrl.Request
+		for _, scene := range scenes.Items {
+			if !scene.Spec.Active {
+				continue
+			}
+			requests = append(requests, ctrl.Request{NamespacedName: types.NamespacedName{
+				Namespace: scene.Namespace,
+				Name:      scene.Name,
+			}})
+		}
+		return requests
+	}
+}
+
+func (r *SceneReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Recorder == nil {
+		r.Recorder = mgr.GetEventRecorderFor("scene-controller")
+	}
+
+	if err := mgr.GetFieldIndexer().IndexField(
+		&smarthomev1alpha1.Scene{}, sceneShutterField, indexSceneShutters); err != nil {
+		return fmt.Errorf("indexing scene shutters: %w", err)
+	}
+	if err := mgr.GetFieldIndexer().IndexField(
+		&smarthomev1alpha1.Scene{}, sceneLightField, indexSceneLights); err != nil {
+		return fmt.Errorf("indexing scene lights: %w", err)
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&smarthomev1alpha1.Scene{}).
+		Watches(&source.Kind{Type: &smarthomev1alpha1.Shutter{}},
+			&handler.EnqueueRequestsFromMapFunc{ToRequests: r.scenesForDevice(sceneShutterField)}).
+		Watches(&source.Kind{Type: &smarthomev1alpha1.Light{}},
+			&handler.EnqueueRequestsFromMapFunc{ToRequests: r.scenesForDevice(sceneLightField)}).
+		Complete(r)
+}
@@ -0,0 +1,218 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	smarthomev1alpha1 "github.com/loodse/godays-2020-k8s-workshop/smart-home/api/v1alpha1"
+)
+
+func testScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := smarthomev1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return scheme
+}
+
+// testShutter returns the reconciled Shutter "default/bedroom".
+func testShutter(closedPercentage, targetPercentage int) *smarthomev1alpha1.Shutter {
+	return &smarthomev1alpha1.Shutter{
+		ObjectMeta: metav1.ObjectMeta{Name: "bedroom", Namespace: "default", Generation: 1},
+		Status: smarthomev1alpha1.ShutterStatus{
+			ObservedGeneration: 1,
+			ClosedPercentage:   closedPercentage,
+			TargetPercentage:   targetPercentage,
+		},
+	}
+}
+
+func TestSceneProgress(t *testing.T) {
+	evening := &smarthomev1alpha1.ShutterPreset{
+		ObjectMeta: metav1.ObjectMeta{Name: "evening", Namespace: "default"},
+		Spec:       smarthomev1alpha1.ShutterPresetSpec{ClosedPercentage: 70},
+	}
+
+	tests := []struct {
+		name             string
+		sceneShutter     smarthomev1alpha1.SceneShutter
+		objects          []runtime.Object
+		expectedComplete bool
+		expectedMessage  string
+	}{
+		{
+			name:             "reached the target of the scene",
+			sceneShutter:     smarthomev1alpha1.SceneShutter{Name: "bedroom", ClosedPercentage: 50},
+			objects:          []runtime.Object{testShutter(50, 50)},
+			expectedComplete: true,
+		},
+		{
+			// the cache still shows the shutter reconciled for its old spec
+			name:            "not reconciled for the scene yet",
+			sceneShutter:    smarthomev1alpha1.SceneShutter{Name: "bedroom", ClosedPercentage: 50},
+			objects:         []runtime.Object{testShutter(0, 0)},
+			expectedMessage: "targets 0% instead of 50%",
+		},
+		{
+			name:            "reached the target of another controller",
+			sceneShutter:    smarthomev1alpha1.SceneShutter{Name: "bedroom", ClosedPercentage: 50},
+			objects:         []runtime.Object{testShutter(30, 30)},
+			expectedMessage: "targets 30% instead of 50%",
+		},
+		{
+			name:            "moving",
+			sceneShutter:    smarthomev1alpha1.SceneShutter{Name: "bedroom", ClosedPercentage: 50},
+			objects:         []runtime.Object{testShutter(20, 50)},
+			expectedMessage: "at 20% of 50%",
+		},
+		{
+			name:         "waiting for the shutter controller",
+			sceneShutter: smarthomev1alpha1.SceneShutter{Name: "bedroom", ClosedPercentage: 50},
+			objects: []runtime.Object{&smarthomev1alpha1.Shutter{
+				ObjectMeta: metav1.ObjectMeta{Name: "bedroom", Namespace: "default", Generation: 2},
+				Status:     smarthomev1alpha1.ShutterStatus{ObservedGeneration: 1, ClosedPercentage: 50, TargetPercentage: 50},
+			}},
+			expectedMessage: "waiting for the shutter to be reconciled",
+		},
+		{
+			name:         "locked",
+			sceneShutter: smarthomev1alpha1.SceneShutter{Name: "bedroom", ClosedPercentage: 50},
+			objects: []runtime.Object{func() runtime.Object {
+				shutter := testShutter(0, 0)
+				shutter.Status.LockedBy = "storm"
+				return shutter
+			}()},
+			expectedMessage: "locked by storm",
+		},
+		{
+			name:             "preset position",
+			sceneShutter:     smarthomev1alpha1.SceneShutter{Name: "bedroom", Position: "evening"},
+			objects:          []runtime.Object{evening, testShutter(70, 70)},
+			expectedComplete: true,
+		},
+		{
+			name:             "built-in position",
+			sceneShutter:     smarthomev1alpha1.SceneShutter{Name: "bedroom", Position: smarthomev1alpha1.ShutterPositionClosed},
+			objects:          []runtime.Object{testShutter(100, 100)},
+			expectedComplete: true,
+		},
+		{
+			name:            "unknown position",
+			sceneShutter:    smarthomev1alpha1.SceneShutter{Name: "bedroom", Position: "sunset"},
+			objects:         []runtime.Object{testShutter(0, 0)},
+			expectedMessage: `unknown position "sunset"`,
+		},
+		{
+			name:            "missing shutter",
+			sceneShutter:    smarthomev1alpha1.SceneShutter{Name: "bedroom", ClosedPercentage: 50},
+			expectedMessage: "shutter not found",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scene := &smarthomev1alpha1.Scene{
+				ObjectMeta: metav1.ObjectMeta{Name: "movie", Namespace: "default"},
+				Spec: smarthomev1alpha1.SceneSpec{
+					Active:   true,
+					Shutters: []smarthomev1alpha1.SceneShutter{test.sceneShutter},
+				},
+			}
+			r := &SceneReconciler{Client: fake.NewFakeClientWithScheme(testScheme(t), test.objects...)}
+
+			complete, err := r.progress(context.Background(), scene)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if complete != test.expectedComplete {
+				t.Errorf("expected complete %t, got %t", test.expectedComplete, complete)
+			}
+			status := scene.Status.Shutters[0]
+			if status.Complete != test.expectedComplete || status.Message != test.expectedMessage {
+				t.Errorf("expected shutter status complete %t with message %q, got %t with message %q",
+					test.expectedComplete, test.expectedMessage, status.Complete, status.Message)
+			}
+		})
+	}
+}
+
+func TestSceneLockedShutter(t *testing.T) {
+	shutter := testShutter(0, 0)
+	shutter.Status.LockedBy = "storm"
+	scene := &smarthomev1alpha1.Scene{
+		ObjectMeta: metav1.ObjectMeta{Name: "movie", Namespace: "default", Generation: 1},
+		Spec: smarthomev1alpha1.SceneSpec{
+			Active:   true,
+			Shutters: []smarthomev1alpha1.SceneShutter{{Name: "bedroom", ClosedPercentage: 50}},
+		},
+	}
+	c := fake.NewFakeClientWithScheme(testScheme(t), shutter, scene)
+	recorder := record.NewFakeRecorder(10)
+	r := &SceneReconciler{Client: c, Log: ctrl.Log, Recorder: recorder}
+	ctx := context.Background()
+	sceneKey := types.NamespacedName{Namespace: "default", Name: "movie"}
+	shutterKey := types.NamespacedName{Namespace: "default", Name: "bedroom"}
+
+	// the scene waits for the locked shutter, the event is only recorded once
+	for i := 0; i < 2; i++ {
+		result, err := r.Reconcile(ctrl.Request{NamespacedName: sceneKey})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result != (ctrl.Result{}) {
+			t.Errorf("expected no requeue, got %+v", result)
+		}
+	}
+	if len(recorder.Events) != 1 {
+		t.Errorf("expected a single event, got %d", len(recorder.Events))
+	}
+	expectedEvent := "Warning ShuttersLocked Skipped shutters locked by a safety interlock: bedroom"
+	if event := <-recorder.Events; event != expectedEvent {
+		t.Errorf("expected event %q, got %q", expectedEvent, event)
+	}
+	if err := c.Get(ctx, sceneKey, scene); err != nil {
+		t.Fatal(err)
+	}
+	if scene.Status.Phase != smarthomev1alpha1.SceneApplying || scene.Status.AppliedGeneration != 0 {
+		t.Errorf("expected the scene to be applying, got phase %s with applied generation %d",
+			scene.Status.Phase, scene.Status.AppliedGeneration)
+	}
+	completed := smarthomev1alpha1.FindCondition(scene.Status.Conditions, smarthomev1alpha1.SceneCompleted)
+	if completed == nil || completed.Reason != reasonShuttersLocked {
+		t.Errorf("expected the scene to wait for locked shutters, got %+v", completed)
+	}
+	if message := scene.Status.Shutters[0].Message; message != "locked by storm" {
+		t.Errorf("expected the shutter status to report the lock, got %q", message)
+	}
+
+	// the scene is applied, once the shutter is unlocked
+	if err := c.Get(ctx, shutterKey, shutter); err != nil {
+		t.Fatal(err)
+	}
+	shutter.Status.LockedBy = ""
+	if err := c.Status().Update(ctx, shutter); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(ctrl.Request{NamespacedName: sceneKey}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.Get(ctx, shutterKey, shutter); err != nil {
+		t.Fatal(err)
+	}
+	if shutter.Spec.ClosedPercentage != 50 {
+		t.Errorf("expected the shutter to be moved to 50%%, got %d%%", shutter.Spec.ClosedPercentage)
+	}
+	if err := c.Get(ctx, sceneKey, scene); err != nil {
+		t.Fatal(err)
+	}
+	if scene.Status.AppliedGeneration != 1 {
+		t.Errorf("expected the scene to be applied, got applied generation %d", scene.Status.AppliedGeneration)
+	}
+}
@@ -17,25 +17,57 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	smarthomev1alpha1 "github.com/loodse/godays-2020-k8s-workshop/smart-home/api/v1alpha1"
 	"github.com/loodse/godays-2020-k8s-workshop/smart-home/pkg/smarthome"
 )
 
+// notFoundRequeueInterval is how long to wait before checking again,
+// whether a device missing from the smart home inventory was registered.
+const notFoundRequeueInterval = 30 * time.Second
+
+// Reasons of the Events recorded by the ShutterReconciler.
+const (
+	reasonMovementStarted  = "MovementStarted"
+	reasonTargetReached    = "TargetReached"
+	reasonStopped          = "Stopped"
+	reasonValidationFailed = "ValidationFailed"
+	reasonNotFound         = "NotFound"
+	reasonUnknownPosition  = "UnknownPosition"
+	reasonLocked           = "Locked"
+	reasonUnlocked         = "Unlocked"
+	reasonBackendError     = "BackendError"
+)
+
 // ShutterReconciler reconciles a Shutter object
 type ShutterReconciler struct {
 	client.Client
 	Log             logr.Logger
+	Recorder        record.EventRecorder
 	SmartHomeClient *smarthome.Client
 }
 
 // +kubebuilder:rbac:groups=smarthome.loodse.io,resources=shutters,verbs=get;list;watch;create;update
 // +kubebuilder:rbac:groups=smarthome.loodse.io,resources=shutters/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=smarthome.loodse.io,resources=shutterpresets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=smarthome.loodse.io,resources=clustershutterpresets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=smarthome.loodse.io,resources=safetyinterlocks,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 func (r *ShutterReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	var (
@@ -50,41 +82,287 @@ func (r *ShutterReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		return result, client.IgnoreNotFound(err)
 	}
 
-	// Just update the Shutter - it will not move when it's already in position
-	// If you have a LOT of shutters and want to save network bandwith,
-	// you can also check the state of the shutter first.
-	if err := r.SmartHomeClient.Shutters().Set(ctx, req.NamespacedName.String(), shutter.Spec.ClosedPercentage); err != nil {
-		return result, fmt.Errorf("updating shutter: %v", err)
+	// An active SafetyInterlock overrides the spec of the Shutter.
+	interlock, err := r.activeInterlock(ctx, shutter)
+	if err != nil {
+		return result, err
 	}
+	r.recordLock(shutter, interlock)
+	setShutterLockStatus(shutter, interlock)
 
-	state, err := r.SmartHomeClient.Shutters().Get(ctx, req.NamespacedName.String())
+	previousPhase := shutter.Status.Phase
+	var closedPercentage int
+	if interlock != nil {
+		closedPercentage = interlock.Spec.SafeClosedPercentage
+	} else {
+		closedPercentage, err = r.resolvePosition(ctx, shutter)
+	}
+	var state smarthome.Shutter
+	if err == nil {
+		state, err = r.updateDevice(ctx, req.NamespacedName.String(), shutter, closedPercentage)
+	}
 	if err != nil {
-		return result, fmt.Errorf("checking shutter state: %v", err)
+		// Tell the rest of the system what went wrong, before retrying.
+		r.recordError(shutter, err)
+		setShutterErrorStatus(shutter, err)
+		if err := r.Client.Status().Update(ctx, shutter); err != nil {
+			return result, fmt.Errorf("updating shutter status: %w", err)
+		}
+		return shutterErrorResult(err)
 	}
 
 	// Update the Status of the shutter, to tell the rest of the system what is going on.
+	r.recordTransition(shutter, previousPhase, state)
+	setShutterStatus(shutter, state)
+	if err := r.Client.Status().Update(ctx, shutter); err != nil {
+		return result, fmt.Errorf("updating shutter status: %w", err)
+	}
+
+	// No need to requeue while the Shutter is moving,
+	// we are notified via watchShutters whenever the Shutter moves or stops.
+	return result, nil
+}
+
+// recordTransition records an Event, when the Shutter starts or stops moving.
+func (r *ShutterReconciler) recordTransition(
+	shutter *smarthomev1alpha1.Shutter, previousPhase smarthomev1alpha1.ShutterPhaseTypes, state smarthome.Shutter,
+) {
+	switch {
+	case state.Moving && previousPhase != smarthomev1alpha1.ShutterMoving:
+		r.Recorder.Eventf(shutter, corev1.EventTypeNormal, reasonMovementStarted,
+			"Moving from %d%% to %d%%", state.Current, state.Target)
+
+	case !state.Moving && previousPhase == smarthomev1alpha1.ShutterMoving && state.Current == state.Target:
+		r.Recorder.Eventf(shutter, corev1.EventTypeNormal, reasonTargetReached,
+			"Reached %d%%", state.Current)
+
+	case !state.Moving && previousPhase == smarthomev1alpha1.ShutterMoving:
+		r.Recorder.Eventf(shutter, corev1.EventTypeWarning, reasonStopped,
+			"Stopped at %d%% instead of %d%%", state.Current, state.Target)
+	}
+}
+
+// recordError records an Event for an error of the smart home.
+func (r *ShutterReconciler) recordError(shutter *smarthomev1alpha1.Shutter, err error) {
+	var (
+		validationErr      smarthome.ValidationError
+		notFoundErr        smarthome.NotFoundError
+		unknownPositionErr unknownPositionError
+	)
+	switch {
+	case errors.As(err, &validationErr):
+		r.Recorder.Event(shutter, corev1.EventTypeWarning, reasonValidationFailed, err.Error())
+	case errors.As(err, &unknownPositionErr):
+		r.Recorder.Event(shutter, corev1.EventTypeWarning, reasonUnknownPosition, err.Error())
+	case errors.As(err, &notFoundErr):
+		r.Recorder.Event(shutter, corev1.EventTypeWarning, reasonNotFound, err.Error())
+	default:
+		r.Recorder.Event(shutter, corev1.EventTypeWarning, reasonBackendError, err.Error())
+	}
+}
+
+// shutterErrorResult returns how to retry after an error of the smart home.
+func shutterErrorResult(err error) (ctrl.Result, error) {
+	var (
+		validationErr      smarthome.ValidationError
+		notFoundErr        smarthome.NotFoundError
+		unknownPositionErr unknownPositionError
+	)
+	switch {
+	case errors.As(err, &validationErr):
+		// Retrying will not help, we have to wait for the Shutter spec to change.
+		return ctrl.Result{}, nil
+	case errors.As(err, &unknownPositionErr):
+		// We are notified via the preset watches, when the position is defined.
+		return ctrl.Result{}, nil
+	case errors.As(err, &notFoundErr):
+		// Check again later, as the Shutter might be registered in the meantime.
+		return ctrl.Result{RequeueAfter: notFoundRequeueInterval}, nil
+	}
+	// Back off through the controller.
+	return ctrl.Result{}, err
+}
+
+// setShutterStatus reports the state of the smart home shutter in the Shutter status.
+func setShutterStatus(shutter *smarthomev1alpha1.Shutter, state smarthome.Shutter) {
+	if shutter.Status.ClosedPercentage != state.Current {
+		now := metav1.Now()
+		shutter.Status.LastMovedTime = &now
+	}
+
 	shutter.Status.ObservedGeneration = shutter.Generation
+	shutter.Status.Position = shutter.Spec.Position
 	shutter.Status.ClosedPercentage = state.Current
+	shutter.Status.TargetPercentage = state.Target
 	if state.Moving {
 		shutter.Status.Phase = smarthomev1alpha1.ShutterMoving
 	} else {
 		shutter.Status.Phase = smarthomev1alpha1.ShutterIdle
 	}
-	if err := r.Client.Status().Update(ctx, shutter); err != nil {
-		return result, fmt.Errorf("updating shutter status: %v", err)
+
+	setShutterCondition(shutter, smarthomev1alpha1.ShutterReady, smarthomev1alpha1.ConditionTrue, "Ready", "")
+	setShutterCondition(shutter, smarthomev1alpha1.ShutterReachable, smarthomev1alpha1.ConditionTrue, "Found", "")
+	switch {
+	case state.Moving:
+		setShutterCondition(shutter, smarthomev1alpha1.ShutterInPosition, smarthomev1alpha1.ConditionFalse, "Moving",
+			fmt.Sprintf("moving from %d%% to %d%%", state.Current, state.Target))
+		setShutterCondition(shutter, smarthomev1alpha1.ShutterDegraded, smarthomev1alpha1.ConditionFalse, "AsExpected", "")
+
+	case state.Current != state.Target:
+		message := fmt.Sprintf("stopped at %d%% instead of %d%%", state.Current, state.Target)
+		setShutterCondition(shutter, smarthomev1alpha1.ShutterInPosition, smarthomev1alpha1.ConditionFalse, "Stopped", message)
+		setShutterCondition(shutter, smarthomev1alpha1.ShutterDegraded, smarthomev1alpha1.ConditionTrue, "Stopped", message)
+
+	default:
+		setShutterCondition(shutter, smarthomev1alpha1.ShutterInPosition, smarthomev1alpha1.ConditionTrue, "InPosition", "")
+		setShutterCondition(shutter, smarthomev1alpha1.ShutterDegraded, smarthomev1alpha1.ConditionFalse, "AsExpected", "")
 	}
+}
 
-	// When the Shutter is not at target position, requeue this entry to check again.
-	if state.Current != state.Target {
-		result.Requeue = true
-		return result, nil
+// setShutterErrorStatus reports an error of the smart home in the Shutter status.
+func setShutterErrorStatus(shutter *smarthomev1alpha1.Shutter, err error) {
+	var (
+		validationErr      smarthome.ValidationError
+		notFoundErr        smarthome.NotFoundError
+		unknownPositionErr unknownPositionError
+	)
+	reachable, reason := smarthomev1alpha1.ConditionFalse, "BackendError"
+	switch {
+	case errors.As(err, &validationErr):
+		// the smart home rejected the spec, so it must have been reachable
+		reachable, reason = smarthomev1alpha1.ConditionTrue, "InvalidSpec"
+	case errors.As(err, &unknownPositionErr):
+		// the smart home was not contacted at all
+		reachable, reason = smarthomev1alpha1.ConditionUnknown, reasonUnknownPosition
+	case errors.As(err, &notFoundErr):
+		reason = "NotFound"
 	}
 
-	return result, nil
+	shutter.Status.ObservedGeneration = shutter.Generation
+	shutter.Status.Phase = smarthomev1alpha1.ShutterError
+	setShutterCondition(shutter, smarthomev1alpha1.ShutterReady, smarthomev1alpha1.ConditionFalse, reason, err.Error())
+	setShutterCondition(shutter, smarthomev1alpha1.ShutterReachable, reachable, reason, err.Error())
+	setShutterCondition(shutter, smarthomev1alpha1.ShutterInPosition, smarthomev1alpha1.ConditionUnknown, reason, err.Error())
+	setShutterCondition(shutter, smarthomev1alpha1.ShutterDegraded, smarthomev1alpha1.ConditionTrue, reason, err.Error())
+}
+
+func setShutterCondition(
+	shutter *smarthomev1alpha1.Shutter, conditionType string,
+	status smarthomev1alpha1.ConditionStatus, reason, message string,
+) {
+	smarthomev1alpha1.SetCondition(&shutter.Status.Conditions, smarthomev1alpha1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: shutter.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// updateDevice moves the smart home shutter to the given position and returns its current state.
+func (r *ShutterReconciler) updateDevice(
+	ctx context.Context, name string, shutter *smarthomev1alpha1.Shutter, closedPercentage int,
+) (smarthome.Shutter, error) {
+	motion := smarthome.DefaultMotionProfile
+	if shutter.Spec.Motion != nil {
+		// the defaulting webhook may not be deployed, e.g. when running locally
+		m := shutter.Spec.Motion.DeepCopy()
+		m.Default()
+		motion = smarthome.MotionProfile{
+			CloseTime:    m.CloseTime.Duration,
+			OpenTime:     m.OpenTime.Duration,
+			Acceleration: m.Acceleration.Duration,
+			DeadTime:     m.DeadTime.Duration,
+		}
+	}
+	if err := r.SmartHomeClient.Shutters().SetMotionProfile(ctx, name, motion); err != nil {
+		return smarthome.Shutter{}, fmt.Errorf("updating shutter motion profile: %w", err)
+	}
+
+	state, err := r.SmartHomeClient.Shutters().Get(ctx, name)
+	if err != nil {
+		return smarthome.Shutter{}, fmt.Errorf("checking shutter state: %w", err)
+	}
+	if state.Target == closedPercentage {
+		// a Set may wake up the motor and its state changes trigger
+		// the next reconcile through the watch, so we only set new targets
+		return state, nil
+	}
+
+	if err := r.SmartHomeClient.Shutters().Set(ctx, name, closedPercentage); err != nil {
+		return smarthome.Shutter{}, fmt.Errorf("updating shutter: %w", err)
+	}
+	state, err = r.SmartHomeClient.Shutters().Get(ctx, name)
+	if err != nil {
+		return smarthome.Shutter{}, fmt.Errorf("checking shutter state: %w", err)
+	}
+	return state, nil
 }
 
 func (r *ShutterReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Recorder == nil {
+		r.Recorder = mgr.GetEventRecorderFor("shutter-controller")
+	}
+
+	events := make(chan event.GenericEvent)
+	if err := mgr.Add(manager.RunnableFunc(func(stop <-chan struct{}) error {
+		return r.watchShutters(stop, events)
+	})); err != nil {
+		return fmt.Errorf("adding shutter watch: %w", err)
+	}
+
+	if err := mgr.GetFieldIndexer().IndexField(
+		&smarthomev1alpha1.Shutter{}, shutterPositionField, indexShutterPosition); err != nil {
+		return fmt.Errorf("indexing shutter positions: %w", err)
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&smarthomev1alpha1.Shutter{}).
+		Watches(&source.Channel{Source: events}, &handler.EnqueueRequestForObject{}).
+		Watches(&source.Kind{Type: &smarthomev1alpha1.ShutterPreset{}},
+			&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.shuttersForPreset)}).
+		Watches(&source.Kind{Type: &smarthomev1alpha1.ClusterShutterPreset{}},
+			&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.shuttersForPreset)}).
+		Watches(&source.Kind{Type: &smarthomev1alpha1.SafetyInterlock{}},
+			&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.shuttersForInterlock)}).
 		Complete(r)
 }
+
+// watchShutters forwards state changes of smart home shutters
+// as events for the matching Shutter objects, until stop is closed.
+func (r *ShutterReconciler) watchShutters(stop <-chan struct{}, events chan<- event.GenericEvent) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	shutterEvents, err := r.SmartHomeClient.Shutters().Watch(ctx)
+	if err != nil {
+		return fmt.Errorf("watching shutters: %w", err)
+	}
+
+	for {
+		select {
+		case <-stop:
+			return nil
+
+		case e, ok := <-shutterEvents:
+			if !ok {
+				return nil
+			}
+
+			namespace, name, err := cache.SplitMetaNamespaceKey(e.Shutter.Name)
+			if err != nil {
+				r.Log.Error(err, "invalid shutter name", "shutter", e.Shutter.Name)
+				continue
+			}
+			shutter := &smarthomev1alpha1.Shutter{}
+			shutter.Namespace = namespace
+			shutter.Name = name
+
+			select {
+			case events <- event.GenericEvent{Meta: shutter, Object: shutter}:
+			case <-stop:
+				return nil
+			}
+		}
+	}
+}
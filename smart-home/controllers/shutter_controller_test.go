@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	smarthomev1alpha1 "github.com/loodse/godays-2020-k8s-workshop/smart-home/api/v1alpha1"
+	"github.com/loodse/godays-2020-k8s-workshop/smart-home/pkg/smarthome"
+)
+
+func TestShutterErrors(t *testing.T) {
+	tests := []struct {
+		name              string
+		err               error
+		expectedResult    ctrl.Result
+		expectedRetry     bool
+		expectedEvent     string
+		expectedReason    string
+		expectedReachable smarthomev1alpha1.ConditionStatus
+	}{
+		{
+			name:              "validation error",
+			err:               fmt.Errorf("updating shutter: %w", smarthome.ValidationError("closed percentage out of range")),
+			expectedEvent:     "Warning ValidationFailed updating shutter: closed percentage out of range",
+			expectedReason:    "InvalidSpec",
+			expectedReachable: smarthomev1alpha1.ConditionTrue,
+		},
+		{
+			name:              "unknown position",
+			err:               unknownPositionError(`position "sunset" is not defined`),
+			expectedEvent:     `Warning UnknownPosition position "sunset" is not defined`,
+			expectedReason:    "UnknownPosition",
+			expectedReachable: smarthomev1alpha1.ConditionUnknown,
+		},
+		{
+			name:              "not found",
+			err:               fmt.Errorf("checking shutter state: %w", smarthome.NotFoundError("shutter not found")),
+			expectedResult:    ctrl.Result{RequeueAfter: notFoundRequeueInterval},
+			expectedEvent:     "Warning NotFound checking shutter state: shutter not found",
+			expectedReason:    "NotFound",
+			expectedReachable: smarthomev1alpha1.ConditionFalse,
+		},
+		{
+			name:              "transient error",
+			err:               fmt.Errorf("updating shutter: %w", smarthome.FaultError("device unreachable")),
+			expectedRetry:     true,
+			expectedEvent:     "Warning BackendError updating shutter: device unreachable",
+			expectedReason:    "BackendError",
+			expectedReachable: smarthomev1alpha1.ConditionFalse,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			shutter := &smarthomev1alpha1.Shutter{
+				ObjectMeta: metav1.ObjectMeta{Name: "bedroom", Namespace: "default", Generation: 2},
+			}
+
+			result, err := shutterErrorResult(test.err)
+			if result != test.expectedResult {
+				t.Errorf("expected result %+v, got %+v", test.expectedResult, result)
+			}
+			if test.expectedRetry && !errors.Is(err, test.err) {
+				t.Errorf("expected the error to be returned for a retry with backoff, got %v", err)
+			}
+			if !test.expectedRetry && err != nil {
+				t.Errorf("expected no retry with backoff, got %v", err)
+			}
+
+			recorder := record.NewFakeRecorder(1)
+			r := &ShutterReconciler{Recorder: recorder}
+			r.recordError(shutter, test.err)
+			if event := <-recorder.Events; event != test.expectedEvent {
+				t.Errorf("expected event %q, got %q", test.expectedEvent, event)
+			}
+
+			setShutterErrorStatus(shutter, test.err)
+			if shutter.Status.Phase != smarthomev1alpha1.ShutterError {
+				t.Errorf("expected phase %s, got %s", smarthomev1alpha1.ShutterError, shutter.Status.Phase)
+			}
+			if shutter.Status.ObservedGeneration != 2 {
+				t.Errorf("expected observed generation 2, got %d", shutter.Status.ObservedGeneration)
+			}
+			expectedConditions := map[string]smarthomev1alpha1.ConditionStatus{
+				smarthomev1alpha1.ShutterReady:      smarthomev1alpha1.ConditionFalse,
+				smarthomev1alpha1.ShutterReachable:  test.expectedReachable,
+				smarthomev1alpha1.ShutterInPosition: smarthomev1alpha1.ConditionUnknown,
+				smarthomev1alpha1.ShutterDegraded:   smarthomev1alpha1.ConditionTrue,
+			}
+			for conditionType, status := range expectedConditions {
+				c := smarthomev1alpha1.FindCondition(shutter.Status.Conditions, conditionType)
+				if c == nil {
+					t.Errorf("expected condition %s", conditionType)
+					continue
+				}
+				if c.Status != status || c.Reason != test.expectedReason || c.Message != test.err.Error() {
+					t.Errorf("expected condition %s to be %s with reason %s, got %s with reason %s: %s",
+						conditionType, status, test.expectedReason, c.Status, c.Reason, c.Message)
+				}
+			}
+		})
+	}
+}
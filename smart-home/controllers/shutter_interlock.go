@@ -0,0 +1,115 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	smarthomev1alpha1 "github.com/loodse/godays-2020-k8s-workshop/smart-home/api/v1alpha1"
+)
+
+// activeInterlock returns the active SafetyInterlock locking the Shutter, if any.
+func (r *ShutterReconciler) activeInterlock(
+	ctx context.Context, shutter *smarthomev1alpha1.Shutter,
+) (*smarthomev1alpha1.SafetyInterlock, error) {
+	interlocks := &smarthomev1alpha1.SafetyInterlockList{}
+	if err := r.List(ctx, interlocks, client.InNamespace(shutter.Namespace)); err != nil {
+		return nil, fmt.Errorf("listing safety interlocks: %w", err)
+	}
+	return selectInterlock(shutter, interlocks.Items), nil
+}
+
+// selectInterlock returns the active SafetyInterlock locking the Shutter, if any.
+// When multiple SafetyInterlocks lock the Shutter, the first by name wins,
+// so the Shutter does not flip between their safe positions.
+func selectInterlock(
+	shutter *smarthomev1alpha1.Shutter, interlocks []smarthomev1alpha1.SafetyInterlock,
+) *smarthomev1alpha1.SafetyInterlock {
+	var selected *smarthomev1alpha1.SafetyInterlock
+	for i := range interlocks {
+		interlock := &interlocks[i]
+		if !interlock.Status.Active {
+			continue
+		}
+		if selected != nil && selected.Name < interlock.Name {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(&interlock.Spec.Selector)
+		if err != nil {
+			continue
+		}
+		if selector.Matches(labels.Set(shutter.Labels)) {
+			selected = interlock
+		}
+	}
+	return selected
+}
+
+// recordLock records an Event, when the Shutter is locked or unlocked.
+func (r *ShutterReconciler) recordLock(
+	shutter *smarthomev1alpha1.Shutter, interlock *smarthomev1alpha1.SafetyInterlock,
+) {
+	switch lockedBy := shutter.Status.LockedBy; {
+	case interlock != nil && lockedBy != interlock.Name:
+		r.Recorder.Eventf(shutter, corev1.EventTypeWarning, reasonLocked,
+			"Locked at %d%% by safety interlock %q", interlock.Spec.SafeClosedPercentage, interlock.Name)
+	case interlock == nil && lockedBy != "":
+		r.Recorder.Eventf(shutter, corev1.EventTypeNormal, reasonUnlocked,
+			"Unlocked by safety interlock %q", lockedBy)
+	}
+}
+
+// setShutterLockStatus reports in the Shutter status, whether a SafetyInterlock locks the Shutter.
+func setShutterLockStatus(shutter *smarthomev1alpha1.Shutter, interlock *smarthomev1alpha1.SafetyInterlock) {
+	if interlock == nil {
+		shutter.Status.LockedBy = ""
+		setShutterCondition(shutter, smarthomev1alpha1.ShutterLocked, smarthomev1alpha1.ConditionFalse, "Unlocked", "")
+		return
+	}
+
+	shutter.Status.LockedBy = interlock.Name
+	setShutterCondition(shutter, smarthomev1alpha1.ShutterLocked, smarthomev1alpha1.ConditionTrue, "SafetyInterlock",
+		fmt.Sprintf("locked at %d%% by safety interlock %q", interlock.Spec.SafeClosedPercentage, interlock.Name))
+}
+
+// shuttersForInterlock maps a SafetyInterlock to the Shutters it selects.
+func (r *ShutterReconciler) shuttersForInterlock(obj handler.MapObject) []ctrl.Request {
+	interlock, ok := obj.Object.(*smarthomev1alpha1.SafetyInterlock)
+	if !ok {
+		return nil
+	}
+
+	shutters := &smarthomev1alpha1.ShutterList{}
+	if err := listSelected(context.Background(), r.Client, shutters, interlock.Namespace, &interlock.Spec.Selector); err != nil {
+		r.Log.Error(err, "listing shutters for safety interlock", "safetyinterlock", interlock.Name)
+		return nil
+	}
+
+	requests := make([]ctrl.Request, len(shutters.Items))
+	for i, shutter := range shutters.Items {
+		requests[i].Namespace = shutter.Namespace
+		requests[i].Name = shutter.Name
+	}
+	return requests
+}
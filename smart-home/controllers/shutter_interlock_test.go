@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	smarthomev1alpha1 "github.com/loodse/godays-2020-k8s-workshop/smart-home/api/v1alpha1"
+)
+
+func TestSelectInterlock(t *testing.T) {
+	shutter := &smarthomev1alpha1.Shutter{
+		ObjectMeta: metav1.ObjectMeta{Name: "terrace", Labels: map[string]string{"side": "south"}},
+	}
+	interlock := func(name string, active bool, side string) smarthomev1alpha1.SafetyInterlock {
+		i := smarthomev1alpha1.SafetyInterlock{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     smarthomev1alpha1.SafetyInterlockStatus{Active: active},
+		}
+		if side != "" {
+			i.Spec.Selector.MatchLabels = map[string]string{"side": side}
+		}
+		return i
+	}
+
+	tests := []struct {
+		name       string
+		interlocks []smarthomev1alpha1.SafetyInterlock
+		expected   string
+	}{
+		{
+			name: "none",
+		},
+		{
+			name:       "inactive",
+			interlocks: []smarthomev1alpha1.SafetyInterlock{interlock("wind", false, "")},
+		},
+		{
+			name:       "not selected",
+			interlocks: []smarthomev1alpha1.SafetyInterlock{interlock("wind", true, "north")},
+		},
+		{
+			name:       "selects all shutters",
+			interlocks: []smarthomev1alpha1.SafetyInterlock{interlock("wind", true, "")},
+			expected:   "wind",
+		},
+		{
+			name: "first by name",
+			interlocks: []smarthomev1alpha1.SafetyInterlock{
+				interlock("wind", true, "south"), interlock("frost", true, ""), interlock("rain", true, ""),
+			},
+			expected: "frost",
+		},
+		{
+			name: "first by name in any order",
+			interlocks: []smarthomev1alpha1.SafetyInterlock{
+				interlock("rain", true, ""), interlock("frost", true, ""), interlock("wind", true, "south"),
+			},
+			expected: "frost",
+		},
+		{
+			name: "first active and selecting by name",
+			interlocks: []smarthomev1alpha1.SafetyInterlock{
+				interlock("wind", true, ""), interlock("frost", false, ""), interlock("hail", true, "north"),
+			},
+			expected: "wind",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var name string
+			if i := selectInterlock(shutter, test.interlocks); i != nil {
+				name = i.Name
+			}
+			if name != test.expected {
+				t.Errorf("expected interlock %q, got %q", test.expected, name)
+			}
+		})
+	}
+}
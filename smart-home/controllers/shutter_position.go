@@ -0,0 +1,113 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	smarthomev1alpha1 "github.com/loodse/godays-2020-k8s-workshop/smart-home/api/v1alpha1"
+)
+
+// shutterPositionField indexes Shutters by their preset position.
+const shutterPositionField = "spec.position"
+
+// unknownPositionError is returned for positions without a matching preset.
+type unknownPositionError string
+
+func (e unknownPositionError) Error() string {
+	return string(e)
+}
+
+// resolvePosition returns the closed percentage the Shutter should move to.
+// Presets in the namespace of the Shutter take precedence over cluster wide presets,
+// which take precedence over the built-in positions.
+func (r *ShutterReconciler) resolvePosition(ctx context.Context, shutter *smarthomev1alpha1.Shutter) (int, error) {
+	return resolveShutterPosition(ctx, r.Client, shutter.Namespace, shutter.Spec.Position, shutter.Spec.ClosedPercentage)
+}
+
+// resolveShutterPosition returns the closed percentage of a position in the namespace,
+// or the given closed percentage, when no position is set.
+func resolveShutterPosition(
+	ctx context.Context, c client.Reader, namespace, position string, closedPercentage int,
+) (int, error) {
+	if position == "" {
+		return closedPercentage, nil
+	}
+
+	preset := &smarthomev1alpha1.ShutterPreset{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: position}, preset)
+	if err == nil {
+		return preset.Spec.ClosedPercentage, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return 0, fmt.Errorf("getting shutter preset: %w", err)
+	}
+
+	clusterPreset := &smarthomev1alpha1.ClusterShutterPreset{}
+	err = c.Get(ctx, types.NamespacedName{Name: position}, clusterPreset)
+	if err == nil {
+		return clusterPreset.Spec.ClosedPercentage, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return 0, fmt.Errorf("getting cluster shutter preset: %w", err)
+	}
+
+	switch position {
+	case smarthomev1alpha1.ShutterPositionOpen:
+		return 0, nil
+	case smarthomev1alpha1.ShutterPositionClosed:
+		return 100, nil
+	}
+	return 0, unknownPositionError(fmt.Sprintf("unknown position %q", position))
+}
+
+func indexShutterPosition(obj runtime.Object) []string {
+	shutter := obj.(*smarthomev1alpha1.Shutter)
+	if shutter.Spec.Position == "" {
+		return nil
+	}
+	return []string{shutter.Spec.Position}
+}
+
+// shuttersForPreset maps a ShutterPreset or ClusterShutterPreset
+// to the Shutters using its position.
+func (r *ShutterReconciler) shuttersForPreset(obj handler.MapObject) []ctrl.Request {
+	opts := []client.ListOption{client.MatchingField(shutterPositionField, obj.Meta.GetName())}
+	if namespace := obj.Meta.GetNamespace(); namespace != "" {
+		opts = append(opts, client.InNamespace(namespace))
+	}
+
+	shutters := &smarthomev1alpha1.ShutterList{}
+	if err := r.List(context.Background(), shutters, opts...); err != nil {
+		r.Log.Error(err, "listing shutters for preset", "preset", obj.Meta.GetName())
+		return nil
+	}
+
+	requests := make([]ctrl.Request, len(shutters.Items))
+	for i, shutter := range shutters.Items {
+		requests[i].Namespace = shutter.Namespace
+		requests[i].Name = shutter.Name
+	}
+	return requests
+}
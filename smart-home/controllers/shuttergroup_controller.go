@@ -0,0 +1,198 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	smarthomev1alpha1 "github.com/loodse/godays-2020-k8s-workshop/smart-home/api/v1alpha1"
+)
+
+// ShutterGroupReconciler reconciles a ShutterGroup object
+type ShutterGroupReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=smarthome.loodse.io,resources=shuttergroups,verbs=get;list;watch;create;update
+// +kubebuilder:rbac:groups=smarthome.loodse.io,resources=shuttergroups/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=smarthome.loodse.io,resources=shutters,verbs=get;list;watch;patch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+func (r *ShutterGroupReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	var (
+		ctx    = context.Background()
+		result ctrl.Result
+		_      = r.Log.WithValues("shuttergroup", req.NamespacedName)
+	)
+
+	// Load ShutterGroup instance from cache.
+	group := &smarthomev1alpha1.ShutterGroup{}
+	if err := r.Get(ctx, req.NamespacedName, group); err != nil {
+		return result, client.IgnoreNotFound(err)
+	}
+
+	members := &smarthomev1alpha1.ShutterList{}
+	if err := listSelected(ctx, r.Client, members, group.Namespace, &group.Spec.Selector); err != nil {
+		return result, fmt.Errorf("listing members: %w", err)
+	}
+
+	// Move all members, when the group changed, otherwise just the ones joining
+	// and the ones locked before, so members may still be moved on their own.
+	changed := group.Status.ObservedGeneration != group.Generation
+	known := map[string]bool{}
+	for _, name := range group.Status.Members {
+		known[name] = true
+	}
+	pending := map[string]bool{}
+	for _, name := range group.Status.PendingMembers {
+		known[name] = false
+		pending[name] = true
+	}
+	var locked, newlyLocked []string
+	for i := range members.Items {
+		shutter := &members.Items[i]
+		if !changed && known[shutter.Name] {
+			continue
+		}
+		err := moveShutter(ctx, r.Client, shutter, "", group.Spec.ClosedPercentage)
+		var lockedErr shutterLockedError
+		switch {
+		case errors.As(err, &lockedErr):
+			locked = append(locked, shutter.Name)
+			if !pending[shutter.Name] {
+				newlyLocked = append(newlyLocked, shutter.Name)
+			}
+		case err != nil:
+			return result, err
+		}
+	}
+	recordShuttersLocked(r.Recorder, group, newlyLocked)
+
+	// Update the Status of the group from its members.
+	group.Status.ObservedGeneration = group.Generation
+	sort.Strings(locked)
+	group.Status.PendingMembers = locked
+	setShutterGroupStatus(group, members.Items)
+	if err := r.Client.Status().Update(ctx, group); err != nil {
+		return result, fmt.Errorf("updating shutter group status: %w", err)
+	}
+
+	// No need to requeue, we are notified whenever a member changes.
+	return result, nil
+}
+
+// setShutterGroupStatus aggregates the status of the members into the ShutterGroup status.
+func setShutterGroupStatus(group *smarthomev1alpha1.ShutterGroup, members []smarthomev1alpha1.Shutter) {
+	status := &group.Status
+	status.Members = make([]string, len(members))
+	status.MemberCount = len(members)
+	status.MovingCount = 0
+	status.MinClosedPercentage = 0
+	status.MaxClosedPercentage = 0
+	status.AverageClosedPercentage = 0
+
+	var sum int
+	for i, shutter := range members {
+		status.Members[i] = shutter.Name
+
+		current := shutter.Status.ClosedPercentage
+		if i == 0 || current < status.MinClosedPercentage {
+			status.MinClosedPercentage = current
+		}
+		if i == 0 || current > status.MaxClosedPercentage {
+			status.MaxClosedPercentage = current
+		}
+		sum += current
+
+		if shutter.Status.Phase == smarthomev1alpha1.ShutterMoving {
+			status.MovingCount++
+		}
+	}
+	sort.Strings(status.Members)
+	if len(members) > 0 {
+		status.AverageClosedPercentage = int(math.Round(float64(sum) / float64(len(members))))
+	}
+
+	if status.MovingCount > 0 {
+		status.Phase = smarthomev1alpha1.ShutterGroupMoving
+	} else {
+		status.Phase = smarthomev1alpha1.ShutterGroupIdle
+	}
+}
+
+// groupsForShutter maps a Shutter to the ShutterGroups it is a member of.
+// For label changes, it is called for the old and new Shutter,
+// so both the group the Shutter leaves and the one it joins are notified.
+func (r *ShutterGroupReconciler) groupsForShutter(obj handler.MapObject) []ctrl.Request {
+	groups := &smarthomev1alpha1.ShutterGroupList{}
+	if err := r.List(context.Background(), groups, client.InNamespace(obj.Meta.GetNamespace())); err != nil {
+		r.Log.Error(err, "listing shutter groups for shutter", "shutter", obj.Meta.GetName())
+		return nil
+	}
+
+	var requests []ctrl.Request
+	for _, group := range groups.Items {
+		selector, err := metav1.LabelSelectorAsSelector(&group.Spec.Selector)
+		if err != nil {
+			continue
+		}
+		if !selector.Matches(labels.Set(obj.Meta.GetLabels())) && !contains(group.Status.Members, obj.Meta.GetName()) {
+			continue
+		}
+		requests = append(requests, ctrl.Request{NamespacedName: types.NamespacedName{
+			Namespace: group.Namespace,
+			Name:      group.Name,
+		}})
+	}
+	return requests
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *ShutterGroupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Recorder == nil {
+		r.Recorder = mgr.GetEventRecorderFor("shuttergroup-controller")
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&smarthomev1alpha1.ShutterGroup{}).
+		Watches(&source.Kind{Type: &smarthomev1alpha1.Shutter{}},
+			&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.groupsForShutter)}).
+		Complete(r)
+}
@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	smarthomev1alpha1 "github.com/loodse/godays-2020-k8s-workshop/smart-home/api/v1alpha1"
+)
+
+func groupMember(name string, closedPercentage int, phase smarthomev1alpha1.ShutterPhaseTypes) smarthomev1alpha1.Shutter {
+	return smarthomev1alpha1.Shutter{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Status:     smarthomev1alpha1.ShutterStatus{ClosedPercentage: closedPercentage, Phase: phase},
+	}
+}
+
+func TestSetShutterGroupStatus(t *testing.T) {
+	tests := []struct {
+		name           string
+		status         smarthomev1alpha1.ShutterGroupStatus
+		members        []smarthomev1alpha1.Shutter
+		expectedStatus smarthomev1alpha1.ShutterGroupStatus
+	}{
+		{
+			name: "empty group",
+			expectedStatus: smarthomev1alpha1.ShutterGroupStatus{
+				Phase:   smarthomev1alpha1.ShutterGroupIdle,
+				Members: []string{},
+			},
+		},
+		{
+			name: "single member",
+			members: []smarthomev1alpha1.Shutter{
+				groupMember("kitchen", 40, smarthomev1alpha1.ShutterIdle),
+			},
+			expectedStatus: smarthomev1alpha1.ShutterGroupStatus{
+				Phase:                   smarthomev1alpha1.ShutterGroupIdle,
+				Members:                 []string{"kitchen"},
+				MemberCount:             1,
+				MinClosedPercentage:     40,
+				MaxClosedPercentage:     40,
+				AverageClosedPercentage: 40,
+			},
+		},
+		{
+			name: "moving members",
+			members: []smarthomev1alpha1.Shutter{
+				groupMember("living-room", 100, smarthomev1alpha1.ShutterIdle),
+				groupMember("bedroom", 0, smarthomev1alpha1.ShutterMoving),
+				groupMember("kitchen", 25, smarthomev1alpha1.ShutterMoving),
+			},
+			expectedStatus: smarthomev1alpha1.ShutterGroupStatus{
+				Phase:                   smarthomev1alpha1.ShutterGroupMoving,
+				Members:                 []string{"bedroom", "kitchen", "living-room"},
+				MemberCount:             3,
+				MovingCount:             2,
+				MinClosedPercentage:     0,
+				MaxClosedPercentage:     100,
+				AverageClosedPercentage: 42,
+			},
+		},
+		{
+			name: "average rounded to a full percent",
+			members: []smarthomev1alpha1.Shutter{
+				groupMember("bedroom", 0, smarthomev1alpha1.ShutterIdle),
+				groupMember("kitchen", 1, smarthomev1alpha1.ShutterIdle),
+			},
+			expectedStatus: smarthomev1alpha1.ShutterGroupStatus{
+				Phase:                   smarthomev1alpha1.ShutterGroupIdle,
+				Members:                 []string{"bedroom", "kitchen"},
+				MemberCount:             2,
+				MinClosedPercentage:     0,
+				MaxClosedPercentage:     1,
+				AverageClosedPercentage: 1,
+			},
+		},
+		{
+			// a member no longer matching the selector, or deleted
+			name: "missing member",
+			status: smarthomev1alpha1.ShutterGroupStatus{
+				Phase:                   smarthomev1alpha1.ShutterGroupMoving,
+				Members:                 []string{"bedroom", "kitchen"},
+				MemberCount:             2,
+				MovingCount:             1,
+				MinClosedPercentage:     10,
+				MaxClosedPercentage:     90,
+				AverageClosedPercentage: 50,
+			},
+			members: []smarthomev1alpha1.Shutter{
+				groupMember("kitchen", 90, smarthomev1alpha1.ShutterIdle),
+			},
+			expectedStatus: smarthomev1alpha1.ShutterGroupStatus{
+				Phase:                   smarthomev1alpha1.ShutterGroupIdle,
+				Members:                 []string{"kitchen"},
+				MemberCount:             1,
+				MinClosedPercentage:     90,
+				MaxClosedPercentage:     90,
+				AverageClosedPercentage: 90,
+			},
+		},
+		{
+			name: "all members missing",
+			status: smarthomev1alpha1.ShutterGroupStatus{
+				Phase:                   smarthomev1alpha1.ShutterGroupMoving,
+				Members:                 []string{"kitchen"},
+				MemberCount:             1,
+				MovingCount:             1,
+				MinClosedPercentage:     90,
+				MaxClosedPercentage:     90,
+				AverageClosedPercentage: 90,
+			},
+			expectedStatus: smarthomev1alpha1.ShutterGroupStatus{
+				Phase:   smarthomev1alpha1.ShutterGroupIdle,
+				Members: []string{},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			group := &smarthomev1alpha1.ShutterGroup{Status: test.status}
+			setShutterGroupStatus(group, test.members)
+			if !reflect.DeepEqual(group.Status, test.expectedStatus) {
+				t.Errorf("expected status\n%+v\ngot\n%+v", test.expectedStatus, group.Status)
+			}
+		})
+	}
+}
+
+func TestShutterGroupLockedMember(t *testing.T) {
+	group := &smarthomev1alpha1.ShutterGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "ground-floor", Namespace: "default", Generation: 1},
+		Spec: smarthomev1alpha1.ShutterGroupSpec{
+			Selector:         metav1.LabelSelector{MatchLabels: map[string]string{"floor": "ground"}},
+			ClosedPercentage: 80,
+		},
+	}
+	kitchen := groupMember("kitchen", 0, smarthomev1alpha1.ShutterIdle)
+	kitchen.Labels = map[string]string{"floor": "ground"}
+	kitchen.Status.LockedBy = "storm"
+	bedroom := groupMember("bedroom", 0, smarthomev1alpha1.ShutterIdle)
+	bedroom.Labels = map[string]string{"floor": "ground"}
+	c := fake.NewFakeClientWithScheme(testScheme(t), group, &kitchen, &bedroom)
+	recorder := record.NewFakeRecorder(10)
+	r := &ShutterGroupReconciler{Client: c, Log: ctrl.Log, Recorder: recorder}
+	ctx := context.Background()
+	groupKey := types.NamespacedName{Namespace: "default", Name: "ground-floor"}
+	kitchenKey := types.NamespacedName{Namespace: "default", Name: "kitchen"}
+
+	reconcile := func() {
+		t.Helper()
+		if _, err := r.Reconcile(ctrl.Request{NamespacedName: groupKey}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	closedPercentage := func(name string) int {
+		t.Helper()
+		shutter := &smarthomev1alpha1.Shutter{}
+		if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, shutter); err != nil {
+			t.Fatal(err)
+		}
+		return shutter.Spec.ClosedPercentage
+	}
+
+	// the locked member is skipped and stays pending, the event is only recorded once
+	reconcile()
+	reconcile()
+	if p := closedPercentage("bedroom"); p != 80 {
+		t.Errorf("expected bedroom to be moved to 80%%, got %d%%", p)
+	}
+	if p := closedPercentage("kitchen"); p != 0 {
+		t.Errorf("expected the locked kitchen not to be moved, got %d%%", p)
+	}
+	if len(recorder.Events) != 1 {
+		t.Errorf("expected a single event, got %d", len(recorder.Events))
+	}
+	if err := c.Get(ctx, groupKey, group); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(group.Status.PendingMembers, []string{"kitchen"}) {
+		t.Errorf("expected kitchen to be pending, got %v", group.Status.PendingMembers)
+	}
+
+	// the member is moved to the position of the group, once it is unlocked
+	if err := c.Get(ctx, kitchenKey, &kitchen); err != nil {
+		t.Fatal(err)
+	}
+	kitchen.Status.LockedBy = ""
+	if err := c.Status().Update(ctx, &kitchen); err != nil {
+		t.Fatal(err)
+	}
+	reconcile()
+	if p := closedPercentage("kitchen"); p != 80 {
+		t.Errorf("expected kitchen to be moved to 80%% once unlocked, got %d%%", p)
+	}
+	group = &smarthomev1alpha1.ShutterGroup{}
+	if err := c.Get(ctx, groupKey, group); err != nil {
+		t.Fatal(err)
+	}
+	if len(group.Status.PendingMembers) != 0 {
+		t.Errorf("expected no pending members, got %v", group.Status.PendingMembers)
+	}
+}
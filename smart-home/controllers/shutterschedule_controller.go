@@ -0,0 +1,189 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/robfig/cron/v3"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	smarthomev1alpha1 "github.com/loodse/godays-2020-k8s-workshop/smart-home/api/v1alpha1"
+	"github.com/loodse/godays-2020-k8s-workshop/smart-home/pkg/smarthome"
+)
+
+// Reasons of the Events recorded by the ShutterScheduleReconciler.
+const (
+	reasonScheduled       = "Scheduled"
+	reasonInvalidSchedule = "InvalidSchedule"
+	reasonNeverScheduled  = "NeverScheduled"
+)
+
+// ShutterScheduleReconciler reconciles a ShutterSchedule object
+type ShutterScheduleReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Recorder record.EventRecorder
+	// Clock defaults to the system time.
+	Clock smarthome.Clock
+}
+
+// +kubebuilder:rbac:groups=smarthome.loodse.io,resources=shutterschedules,verbs=get;list;watch;create;update
+// +kubebuilder:rbac:groups=smarthome.loodse.io,resources=shutterschedules/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=smarthome.loodse.io,resources=shutters,verbs=get;list;watch;patch
+
+func (r *ShutterScheduleReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	var (
+		ctx    = context.Background()
+		result ctrl.Result
+		_      = r.Log.WithValues("shutterschedule", req.NamespacedName)
+	)
+
+	// Load ShutterSchedule instance from cache.
+	shutterSchedule := &smarthomev1alpha1.ShutterSchedule{}
+	if err := r.Get(ctx, req.NamespacedName, shutterSchedule); err != nil {
+		return result, client.IgnoreNotFound(err)
+	}
+
+	schedule, err := parseSchedule(shutterSchedule.Spec)
+	if err != nil {
+		// Retrying will not help, we have to wait for the ShutterSchedule spec to change.
+		r.Recorder.Event(shutterSchedule, corev1.EventTypeWarning, reasonInvalidSchedule, err.Error())
+		shutterSchedule.Status.ObservedGeneration = shutterSchedule.Generation
+		shutterSchedule.Status.NextScheduleTime = nil
+		setShutterScheduleReady(shutterSchedule, smarthomev1alpha1.ConditionFalse, reasonInvalidSchedule, err.Error())
+		if err := r.Client.Status().Update(ctx, shutterSchedule); err != nil {
+			return result, fmt.Errorf("updating shutter schedule status: %w", err)
+		}
+		return result, nil
+	}
+
+	// Move the Shutters, if a run is due since the last one.
+	now := r.Clock.Now()
+	since := shutterSchedule.CreationTimestamp.Time
+	if last := shutterSchedule.Status.LastScheduleTime; last != nil {
+		since = last.Time
+	}
+	if run, ok := lastRun(schedule, since, now); ok {
+		count, locked, err := moveShutters(ctx, r.Client, shutterSchedule.Namespace, &shutterSchedule.Spec.Selector,
+			shutterSchedule.Spec.Position, shutterSchedule.Spec.ClosedPercentage)
+		if err != nil {
+			setShutterScheduleReady(shutterSchedule, smarthomev1alpha1.ConditionFalse, "MoveFailed", err.Error())
+			if err := r.Client.Status().Update(ctx, shutterSchedule); err != nil {
+				return result, fmt.Errorf("updating shutter schedule status: %w", err)
+			}
+			return result, err
+		}
+
+		r.Recorder.Eventf(shutterSchedule, corev1.EventTypeNormal, reasonScheduled,
+			"Moved %d shutters to %s", count, shutterScheduleTarget(shutterSchedule.Spec))
+		recordShuttersLocked(r.Recorder, shutterSchedule, locked)
+		shutterSchedule.Status.LastScheduleTime = &metav1.Time{Time: run}
+		shutterSchedule.Status.ShutterCount = count
+	}
+
+	// Update the Status and wait for the next run.
+	shutterSchedule.Status.ObservedGeneration = shutterSchedule.Generation
+	shutterSchedule.Status.NextScheduleTime = nil
+	next := schedule.Next(now)
+	if next.IsZero() {
+		// e.g. on February 30th, we have to wait for the ShutterSchedule spec to change.
+		setShutterScheduleReady(shutterSchedule, smarthomev1alpha1.ConditionFalse, reasonNeverScheduled,
+			fmt.Sprintf("schedule %q never runs", shutterSchedule.Spec.Schedule))
+	} else {
+		shutterSchedule.Status.NextScheduleTime = &metav1.Time{Time: next}
+		setShutterScheduleReady(shutterSchedule, smarthomev1alpha1.ConditionTrue, "Scheduled", "")
+		result.RequeueAfter = next.Sub(now)
+	}
+	if err := r.Client.Status().Update(ctx, shutterSchedule); err != nil {
+		return ctrl.Result{}, fmt.Errorf("updating shutter schedule status: %w", err)
+	}
+	return result, nil
+}
+
+// parseSchedule parses the cron expression of the spec in its time zone.
+// A CRON_TZ= or TZ= prefix of the expression can be used instead of the TimeZone field, not together with it.
+func parseSchedule(spec smarthomev1alpha1.ShutterScheduleSpec) (cron.Schedule, error) {
+	if strings.HasPrefix(spec.Schedule, "CRON_TZ=") || strings.HasPrefix(spec.Schedule, "TZ=") {
+		if spec.TimeZone != "" {
+			return nil, fmt.Errorf("schedule %q sets a time zone, conflicting with time zone %q", spec.Schedule, spec.TimeZone)
+		}
+		schedule, err := cron.ParseStandard(spec.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", spec.Schedule, err)
+		}
+		return schedule, nil
+	}
+
+	location := time.UTC
+	if spec.TimeZone != "" {
+		l, err := time.LoadLocation(spec.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time zone %q: %w", spec.TimeZone, err)
+		}
+		location = l
+	}
+
+	schedule, err := cron.ParseStandard(spec.Schedule)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", spec.Schedule, err)
+	}
+	if s, ok := schedule.(*cron.SpecSchedule); ok {
+		s.Location = location
+	}
+	return schedule, nil
+}
+
+func shutterScheduleTarget(spec smarthomev1alpha1.ShutterScheduleSpec) string {
+	if spec.Position != "" {
+		return spec.Position
+	}
+	return fmt.Sprintf("%d%%", spec.ClosedPercentage)
+}
+
+func setShutterScheduleReady(
+	shutterSchedule *smarthomev1alpha1.ShutterSchedule,
+	status smarthomev1alpha1.ConditionStatus, reason, message string,
+) {
+	smarthomev1alpha1.SetCondition(&shutterSchedule.Status.Conditions, smarthomev1alpha1.Condition{
+		Type:               smarthomev1alpha1.ShutterScheduleReady,
+		Status:             status,
+		ObservedGeneration: shutterSchedule.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+func (r *ShutterScheduleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Recorder == nil {
+		r.Recorder = mgr.GetEventRecorderFor("shutterschedule-controller")
+	}
+	if r.Clock == nil {
+		r.Clock = smarthome.RealClock
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&smarthomev1alpha1.ShutterSchedule{}).
+		Complete(r)
+}
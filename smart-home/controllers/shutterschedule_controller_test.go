@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	smarthomev1alpha1 "github.com/loodse/godays-2020-k8s-workshop/smart-home/api/v1alpha1"
+	"github.com/loodse/godays-2020-k8s-workshop/smart-home/pkg/smarthome"
+)
+
+func mustParseTime(t *testing.T, value string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		name         string
+		spec         smarthomev1alpha1.ShutterScheduleSpec
+		after        string
+		expectedNext string
+		expectedErr  bool
+	}{
+		{
+			name:         "defaults to UTC",
+			spec:         smarthomev1alpha1.ShutterScheduleSpec{Schedule: "0 22 * * *"},
+			after:        "2020-01-01T12:00:00Z",
+			expectedNext: "2020-01-01T22:00:00Z",
+		},
+		{
+			name:         "time zone",
+			spec:         smarthomev1alpha1.ShutterScheduleSpec{Schedule: "0 22 * * *", TimeZone: "Europe/Berlin"},
+			after:        "2020-01-01T12:00:00Z",
+			expectedNext: "2020-01-01T21:00:00Z",
+		},
+		{
+			name:         "time zone prefix",
+			spec:         smarthomev1alpha1.ShutterScheduleSpec{Schedule: "CRON_TZ=Europe/Berlin 0 22 * * *"},
+			after:        "2020-01-01T12:00:00Z",
+			expectedNext: "2020-01-01T21:00:00Z",
+		},
+		{
+			name:         "short time zone prefix",
+			spec:         smarthomev1alpha1.ShutterScheduleSpec{Schedule: "TZ=Europe/Berlin 0 22 * * *"},
+			after:        "2020-01-01T12:00:00Z",
+			expectedNext: "2020-01-01T21:00:00Z",
+		},
+		{
+			name: "time zone prefix and time zone",
+			spec: smarthomev1alpha1.ShutterScheduleSpec{
+				Schedule: "CRON_TZ=Europe/Berlin 0 22 * * *", TimeZone: "Europe/Berlin",
+			},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid time zone",
+			spec:        smarthomev1alpha1.ShutterScheduleSpec{Schedule: "0 22 * * *", TimeZone: "Europe/Nowhere"},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid time zone prefix",
+			spec:        smarthomev1alpha1.ShutterScheduleSpec{Schedule: "CRON_TZ=Europe/Nowhere 0 22 * * *"},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid schedule",
+			spec:        smarthomev1alpha1.ShutterScheduleSpec{Schedule: "0 25 * * *"},
+			expectedErr: true,
+		},
+		{
+			// 02:30 does not exist, when the clocks go forward
+			name:         "skipped by daylight saving time",
+			spec:         smarthomev1alpha1.ShutterScheduleSpec{Schedule: "30 2 * * *", TimeZone: "Europe/Berlin"},
+			after:        "2020-03-28T12:00:00Z",
+			expectedNext: "2020-03-30T00:30:00Z",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			schedule, err := parseSchedule(test.spec)
+			if test.expectedErr {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			next := schedule.Next(mustParseTime(t, test.after))
+			if expected := mustParseTime(t, test.expectedNext); !next.Equal(expected) {
+				t.Errorf("expected next run at %s, got %s", expected, next.UTC())
+			}
+		})
+	}
+}
+
+func TestLastRun(t *testing.T) {
+	tests := []struct {
+		name         string
+		schedule     string
+		since, now   string
+		expectedLast string
+	}{
+		{
+			name:     "not due",
+			schedule: "0 22 * * *",
+			since:    "2020-01-01T12:00:00Z",
+			now:      "2020-01-01T21:59:59Z",
+		},
+		{
+			name:         "due",
+			schedule:     "0 22 * * *",
+			since:        "2020-01-01T12:00:00Z",
+			now:          "2020-01-01T22:00:00Z",
+			expectedLast: "2020-01-01T22:00:00Z",
+		},
+		{
+			name:     "already run",
+			schedule: "0 22 * * *",
+			since:    "2020-01-01T22:00:00Z",
+			now:      "2020-01-01T23:00:00Z",
+		},
+		{
+			name:         "missed runs are run once",
+			schedule:     "0 22 * * *",
+			since:        "2020-01-01T12:00:00Z",
+			now:          "2020-01-04T12:00:00Z",
+			expectedLast: "2020-01-03T22:00:00Z",
+		},
+		{
+			// 02:30 happens twice, when the clocks go back
+			name:         "repeated by daylight saving time",
+			schedule:     "CRON_TZ=Europe/Berlin 30 2 * * *",
+			since:        "2020-10-25T00:30:00Z",
+			now:          "2020-10-25T02:00:00Z",
+			expectedLast: "2020-10-25T01:30:00Z",
+		},
+		{
+			name:     "skipped by daylight saving time",
+			schedule: "CRON_TZ=Europe/Berlin 30 2 * * *",
+			since:    "2020-03-28T12:00:00Z",
+			now:      "2020-03-29T12:00:00Z",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			schedule, err := parseSchedule(smarthomev1alpha1.ShutterScheduleSpec{Schedule: test.schedule})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			last, ok := lastRun(schedule, mustParseTime(t, test.since), mustParseTime(t, test.now))
+			if test.expectedLast == "" {
+				if ok {
+					t.Errorf("expected no run, got %s", last.UTC())
+				}
+				return
+			}
+			if expected := mustParseTime(t, test.expectedLast); !ok || !last.Equal(expected) {
+				t.Errorf("expected last run at %s, got %s (%t)", expected, last.UTC(), ok)
+			}
+		})
+	}
+}
+
+func TestShutterScheduleNeverRuns(t *testing.T) {
+	shutterSchedule := &smarthomev1alpha1.ShutterSchedule{
+		ObjectMeta: metav1.ObjectMeta{Name: "leap", Namespace: "default", Generation: 1},
+		Spec:       smarthomev1alpha1.ShutterScheduleSpec{Schedule: "0 0 30 2 *"},
+	}
+	c := fake.NewFakeClientWithScheme(testScheme(t), shutterSchedule)
+	r := &ShutterScheduleReconciler{
+		Client:   c,
+		Log:      ctrl.Log,
+		Recorder: record.NewFakeRecorder(10),
+		Clock:    smarthome.NewFakeClock(mustParseTime(t, "2020-01-01T12:00:00Z")),
+	}
+
+	key := types.NamespacedName{Namespace: "default", Name: "leap"}
+	result, err := r.Reconcile(ctrl.Request{NamespacedName: key})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != (ctrl.Result{}) {
+		t.Errorf("expected no requeue, got %+v", result)
+	}
+
+	got := &smarthomev1alpha1.ShutterSchedule{}
+	if err := c.Get(context.Background(), key, got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Status.NextScheduleTime != nil {
+		t.Errorf("expected no next schedule time, got %s", got.Status.NextScheduleTime)
+	}
+	ready := smarthomev1alpha1.FindCondition(got.Status.Conditions, smarthomev1alpha1.ShutterScheduleReady)
+	if ready == nil || ready.Status != smarthomev1alpha1.ConditionFalse || ready.Reason != reasonNeverScheduled {
+		t.Errorf("expected the schedule not to be ready, got %+v", ready)
+	}
+}
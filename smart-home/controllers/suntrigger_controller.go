@@ -0,0 +1,189 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	smarthomev1alpha1 "github.com/loodse/godays-2020-k8s-workshop/smart-home/api/v1alpha1"
+	"github.com/loodse/godays-2020-k8s-workshop/smart-home/pkg/smarthome"
+	"github.com/loodse/godays-2020-k8s-workshop/smart-home/pkg/sun"
+)
+
+// Reasons of the Events recorded by the SunTriggerReconciler.
+const (
+	reasonTriggered   = "Triggered"
+	reasonInvalidSpec = "InvalidSpec"
+)
+
+// SunTriggerReconciler reconciles a SunTrigger object
+type SunTriggerReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Recorder record.EventRecorder
+	// Clock defaults to the system time.
+	Clock smarthome.Clock
+}
+
+// +kubebuilder:rbac:groups=smarthome.loodse.io,resources=suntriggers,verbs=get;list;watch;create;update
+// +kubebuilder:rbac:groups=smarthome.loodse.io,resources=suntriggers/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=smarthome.loodse.io,resources=shutters,verbs=get;list;watch;patch
+// +kubebuilder:rbac:groups=smarthome.loodse.io,resources=lights,verbs=get;list;watch;patch
+
+func (r *SunTriggerReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	var (
+		ctx    = context.Background()
+		result ctrl.Result
+		_      = r.Log.WithValues("suntrigger", req.NamespacedName)
+	)
+
+	// Load SunTrigger instance from cache.
+	sunTrigger := &smarthomev1alpha1.SunTrigger{}
+	if err := r.Get(ctx, req.NamespacedName, sunTrigger); err != nil {
+		return result, client.IgnoreNotFound(err)
+	}
+
+	schedule, err := sunSchedule(sunTrigger.Spec)
+	if err != nil {
+		// Retrying will not help, we have to wait for the SunTrigger spec to change.
+		r.Recorder.Event(sunTrigger, corev1.EventTypeWarning, reasonInvalidSpec, err.Error())
+		sunTrigger.Status.ObservedGeneration = sunTrigger.Generation
+		sunTrigger.Status.NextTriggerTime = nil
+		setSunTriggerReady(sunTrigger, smarthomev1alpha1.ConditionFalse, reasonInvalidSpec, err.Error())
+		if err := r.Client.Status().Update(ctx, sunTrigger); err != nil {
+			return result, fmt.Errorf("updating sun trigger status: %w", err)
+		}
+		return result, nil
+	}
+
+	// Change the devices, if the trigger fired since it last did.
+	now := r.Clock.Now()
+	since := sunTrigger.CreationTimestamp.Time
+	if last := sunTrigger.Status.LastTriggerTime; last != nil {
+		since = last.Time
+	}
+	if run, ok := lastRun(schedule, since, now); ok {
+		if err := r.trigger(ctx, sunTrigger); err != nil {
+			setSunTriggerReady(sunTrigger, smarthomev1alpha1.ConditionFalse, "TriggerFailed", err.Error())
+			if err := r.Client.Status().Update(ctx, sunTrigger); err != nil {
+				return result, fmt.Errorf("updating sun trigger status: %w", err)
+			}
+			return result, err
+		}
+
+		r.Recorder.Eventf(sunTrigger, corev1.EventTypeNormal, reasonTriggered,
+			"Moved %d shutters and switched %d lights", sunTrigger.Status.ShutterCount, sunTrigger.Status.LightCount)
+		sunTrigger.Status.LastTriggerTime = &metav1.Time{Time: run}
+	}
+
+	// Update the Status and wait for the next sun event.
+	sunTrigger.Status.ObservedGeneration = sunTrigger.Generation
+	sunTrigger.Status.NextTriggerTime = nil
+	setSunTriggerReady(sunTrigger, smarthomev1alpha1.ConditionTrue, "Scheduled", "")
+	next := schedule.Next(now)
+	if !next.IsZero() {
+		sunTrigger.Status.NextTriggerTime = &metav1.Time{Time: next}
+		result.RequeueAfter = next.Sub(now)
+	}
+	if err := r.Client.Status().Update(ctx, sunTrigger); err != nil {
+		return result, fmt.Errorf("updating sun trigger status: %w", err)
+	}
+	return result, nil
+}
+
+// trigger changes the selected devices and records their number in the status.
+func (r *SunTriggerReconciler) trigger(ctx context.Context, sunTrigger *smarthomev1alpha1.SunTrigger) error {
+	if s := sunTrigger.Spec.Shutters; s != nil {
+		count, locked, err := moveShutters(ctx, r.Client, sunTrigger.Namespace, &s.Selector, s.Position, s.ClosedPercentage)
+		if err != nil {
+			return err
+		}
+		recordShuttersLocked(r.Recorder, sunTrigger, locked)
+		sunTrigger.Status.ShutterCount = count
+	}
+	if l := sunTrigger.Spec.Lights; l != nil {
+		count, err := switchLights(ctx, r.Client, sunTrigger.Namespace, &l.Selector, l.On)
+		if err != nil {
+			return err
+		}
+		sunTrigger.Status.LightCount = count
+	}
+	return nil
+}
+
+// sunSchedule converts the spec into a sun.Schedule.
+func sunSchedule(spec smarthomev1alpha1.SunTriggerSpec) (sun.Schedule, error) {
+	latitude, err := strconv.ParseFloat(spec.Latitude, 64)
+	if err != nil || latitude < -90 || latitude > 90 {
+		return sun.Schedule{}, fmt.Errorf("latitude %q must be between -90 and 90", spec.Latitude)
+	}
+	longitude, err := strconv.ParseFloat(spec.Longitude, 64)
+	if err != nil || longitude < -180 || longitude > 180 {
+		return sun.Schedule{}, fmt.Errorf("longitude %q must be between -180 and 180", spec.Longitude)
+	}
+
+	var event sun.Event
+	switch spec.Event {
+	case smarthomev1alpha1.Sunrise:
+		event = sun.Sunrise
+	case smarthomev1alpha1.Sunset:
+		event = sun.Sunset
+	default:
+		return sun.Schedule{}, fmt.Errorf("unknown event %q", spec.Event)
+	}
+
+	return sun.Schedule{
+		Event:     event,
+		Offset:    spec.Offset.Duration,
+		Latitude:  latitude,
+		Longitude: longitude,
+	}, nil
+}
+
+func setSunTriggerReady(
+	sunTrigger *smarthomev1alpha1.SunTrigger,
+	status smarthomev1alpha1.ConditionStatus, reason, message string,
+) {
+	smarthomev1alpha1.SetCondition(&sunTrigger.Status.Conditions, smarthomev1alpha1.Condition{
+		Type:               smarthomev1alpha1.SunTriggerReady,
+		Status:             status,
+		ObservedGeneration: sunTrigger.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+func (r *SunTriggerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Recorder == nil {
+		r.Recorder = mgr.GetEventRecorderFor("suntrigger-controller")
+	}
+	if r.Clock == nil {
+		r.Clock = smarthome.RealClock
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&smarthomev1alpha1.SunTrigger{}).
+		Complete(r)
+}
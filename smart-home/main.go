@@ -16,14 +16,26 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
+	"github.com/go-logr/logr"
+	uberzap "go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 
 	smarthomev1alpha1 "github.com/loodse/godays-2020-k8s-workshop/smart-home/api/v1alpha1"
 	"github.com/loodse/godays-2020-k8s-workshop/smart-home/controllers"
@@ -31,6 +43,18 @@ import (
 	"github.com/loodse/godays-2020-k8s-workshop/smart-home/pkg/ui"
 )
 
+// shutdownTimeout is how long to wait for controllers and servers to stop,
+// before persisting the device state anyway.
+// It has to stay well below the terminationGracePeriodSeconds of the deployment.
+const shutdownTimeout = 10 * time.Second
+
+// dashboards selectable with --ui
+const (
+	uiTUI  = "tui"
+	uiWeb  = "web"
+	uiNone = "none"
+)
+
 var (
 	scheme   = runtime.NewScheme()
 	setupLog = ctrl.Log.WithName("setup")
@@ -44,15 +68,127 @@ func init() {
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
+	var enableWebhooks bool
+	var backend string
+	var stateDir string
+	var snapshotInterval time.Duration
+	var faults, faultsFile string
+	var shutters, lights, sensors string
+	var sensorAPIAddr string
+	var uiMode, webAddr string
+	var logDevelopment bool
+	var logLines, logVerbosity int
+	var logFile string
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false,
+		"Enable the admission webhooks. Requires serving certificates in /tmp/k8s-webhook-server/serving-certs.")
+	flag.StringVar(&backend, "backend", smarthome.SimulatorBackend,
+		"The smart home backend to control devices with. One of: "+strings.Join(smarthome.Backends(), ", "))
+	flag.StringVar(&stateDir, "state-dir", "/tmp/godays2020", "The directory the smart home device state is persisted in.")
+	flag.DurationVar(&snapshotInterval, "snapshot-interval", 10*time.Second,
+		"The interval in which the smart home device state is persisted. Set to 0 to only persist on shutdown.")
+	flag.StringVar(&faults, "faults", "",
+		`JSON encoded faults to inject into simulated devices, e.g. {"failureRates": {"set": 0.1}, "latency": "200ms"}.`)
+	flag.StringVar(&faultsFile, "faults-file", "",
+		"File containing JSON encoded faults to inject into simulated devices. The file is reloaded on SIGHUP.")
+	flag.StringVar(&shutters, "shutters", "default/living-room,default/bedroom",
+		"Comma separated list of shutters to register with the smart home backend, as namespace/name.")
+	flag.StringVar(&lights, "lights", "default/living-room,default/bedroom",
+		"Comma separated list of lights to register with the smart home backend, as namespace/name.")
+	flag.StringVar(&sensors, "sensors", "wind-speed",
+		"Comma separated list of sensors to register with the smart home backend.")
+	flag.StringVar(&sensorAPIAddr, "sensor-api-addr", "127.0.0.1:8081",
+		"The address the sensor API binds to, to read and set simulated sensors. Set to 0 to disable it.")
+	flag.StringVar(&uiMode, "ui", uiTUI,
+		"The dashboard showing device state and logs. One of: "+strings.Join([]string{uiTUI, uiWeb, uiNone}, ", "))
+	flag.StringVar(&webAddr, "web-addr", ":8082", "The address the web dashboard binds to, when using --ui=web.")
+	flag.BoolVar(&logDevelopment, "log-development", false,
+		"Log human readable instead of JSON, when using --ui=none.")
+	flag.IntVar(&logLines, "log-lines", 1000, "The number of log lines kept by the dashboards.")
+	flag.IntVar(&logVerbosity, "log-verbosity", 0, "The highest V level logged, 0 only logs infos and errors.")
+	flag.StringVar(&logFile, "log-file", "",
+		"File to copy the log lines of the dashboards to, - for stderr with --ui=web.")
 	flag.Parse()
 
-	smartHomeClient := smarthome.NewClient()
-	u := ui.NewUI(smartHomeClient, 1*time.Second)
+	if uiMode != uiTUI && uiMode != uiWeb && uiMode != uiNone {
+		fmt.Fprintf(os.Stderr, "unknown --ui %q\n", uiMode)
+		os.Exit(1)
+	}
+	if logFile == "-" && uiMode == uiTUI {
+		// the log lines would be drawn over the terminal dashboard
+		fmt.Fprintf(os.Stderr, "--log-file=- can not be used with --ui=%s\n", uiTUI)
+		os.Exit(1)
+	}
 
-	ctrl.SetLogger(u.Logger())
+	// the logger is not yet set up, so we print errors directly to stderr
+	store, err := smarthome.NewFileStore(stateDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to create smart home state store: %v\n", err)
+		os.Exit(1)
+	}
+	faultInjector, err := newFaultInjector(faults, faultsFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to configure fault injection: %v\n", err)
+		os.Exit(1)
+	}
+	smartHomeClient, err := smarthome.NewClient(backend, smarthome.BackendOptions{
+		Store:            store,
+		SnapshotInterval: snapshotInterval,
+		Faults:           faultInjector,
+		Inventory: smarthome.Inventory{
+			Shutters: splitList(shutters),
+			Lights:   splitList(lights),
+			Sensors:  splitList(sensors),
+		},
+		ErrorHandler: func(err error) {
+			ctrl.Log.WithName("smarthome").Error(err, "background operation failed")
+		},
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to create smart home client: %v\n", err)
+		os.Exit(1)
+	}
+
+	logOpts := ui.LogOptions{Lines: logLines, Verbosity: logVerbosity}
+	switch logFile {
+	case "":
+	case "-":
+		logOpts.Tee = os.Stderr
+	default:
+		f, err := os.OpenFile(logFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "unable to open log file: %v\n", err)
+			os.Exit(1)
+		}
+		// written unbuffered, so closing on exit is enough
+		logOpts.Tee = f
+	}
+
+	// SIGTERM and SIGINT stop the manager in all modes
+	signals := ctrl.SetupSignalHandler()
+	stop := signals
+	var (
+		tui *ui.UI
+		web *ui.Web
+	)
+	switch uiMode {
+	case uiTUI:
+		tui = ui.NewUI(smartHomeClient, 1*time.Second, logOpts)
+		ctrl.SetLogger(tui.Logger())
+		// quitting the terminal dashboard stops the manager, too
+		go func() {
+			<-signals
+			tui.Stop()
+		}()
+		stop = tui.CloseCh()
+	case uiWeb:
+		web = ui.NewWeb(smartHomeClient, 1*time.Second, logOpts)
+		ctrl.SetLogger(web.Logger())
+	case uiNone:
+		ctrl.SetLogger(newZapLogger(logDevelopment, logVerbosity))
+	}
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:             scheme,
@@ -64,22 +200,284 @@ func main() {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
+	// the manager does not wait for its runnables to return, when it stops
+	runnables := &runnableGroup{Manager: mgr}
+	mgr = runnables
 
 	if err = (&controllers.ShutterReconciler{
 		Client:          mgr.GetClient(),
 		Log:             ctrl.Log.WithName("controllers").WithName("Shutter"),
+		Recorder:        mgr.GetEventRecorderFor("shutter-controller"),
 		SmartHomeClient: smartHomeClient,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Shutter")
 		os.Exit(1)
 	}
+	if err = (&controllers.LightReconciler{
+		Client:          mgr.GetClient(),
+		Log:             ctrl.Log.WithName("controllers").WithName("Light"),
+		SmartHomeClient: smartHomeClient,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Light")
+		os.Exit(1)
+	}
+	if err = (&controllers.ShutterScheduleReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("ShutterSchedule"),
+		Recorder: mgr.GetEventRecorderFor("shutterschedule-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ShutterSchedule")
+		os.Exit(1)
+	}
+	if err = (&controllers.SunTriggerReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("SunTrigger"),
+		Recorder: mgr.GetEventRecorderFor("suntrigger-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "SunTrigger")
+		os.Exit(1)
+	}
+	if err = (&controllers.SceneReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("Scene"),
+		Recorder: mgr.GetEventRecorderFor("scene-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Scene")
+		os.Exit(1)
+	}
+	if err = (&controllers.ShutterGroupReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("ShutterGroup"),
+		Recorder: mgr.GetEventRecorderFor("shuttergroup-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ShutterGroup")
+		os.Exit(1)
+	}
+	if err = (&controllers.SafetyInterlockReconciler{
+		Client:          mgr.GetClient(),
+		Log:             ctrl.Log.WithName("controllers").WithName("SafetyInterlock"),
+		Recorder:        mgr.GetEventRecorderFor("safetyinterlock-controller"),
+		SmartHomeClient: smartHomeClient,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "SafetyInterlock")
+		os.Exit(1)
+	}
+	if enableWebhooks {
+		if err = (&smarthomev1alpha1.Shutter{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Shutter")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
-	go u.Run()
+	if sensorAPIAddr != "0" {
+		if err := mgr.Add(newHTTPServer(sensorAPIAddr, smarthome.NewSensorAPI(smartHomeClient.Sensors()))); err != nil {
+			setupLog.Error(err, "unable to add sensor API")
+			os.Exit(1)
+		}
+	}
+
+	if web != nil {
+		if err := mgr.Add(web); err != nil {
+			setupLog.Error(err, "unable to add web dashboard")
+			os.Exit(1)
+		}
+		if err := mgr.Add(newHTTPServer(webAddr, web.Handler())); err != nil {
+			setupLog.Error(err, "unable to add web dashboard server")
+			os.Exit(1)
+		}
+	}
+	if tui != nil {
+		tui.EnableControl(mgr.GetClient())
+		go tui.Run()
+	}
 
 	setupLog.Info("starting manager")
-	if err := mgr.Start(u.CloseCh()); err != nil {
-		setupLog.Error(err, "problem running manager")
-		os.Exit(1)
+	runErr := mgr.Start(stop)
+	if tui != nil {
+		// restore the terminal, the log pane is gone from here on
+		tui.Stop()
+		<-tui.CloseCh()
+		setupLog = newZapLogger(logDevelopment, logVerbosity).WithName("setup")
+	}
+
+	setupLog.Info("shutting down")
+	exitCode := 0
+	if runErr != nil {
+		setupLog.Error(runErr, "problem running manager")
+		exitCode = 1
+	}
+	if !runnables.wait(shutdownTimeout) {
+		setupLog.Error(fmt.Errorf("timed out after %s", shutdownTimeout), "controllers and servers did not stop")
+		exitCode = 1
+	}
+	// persist the device state, after the last change
+	if err := smartHomeClient.Close(); err != nil {
+		setupLog.Error(err, "unable to close smart home client")
+		exitCode = 1
+	}
+	os.Exit(exitCode)
+}
+
+// newFaultInjector configures fault injection from the given inline JSON or file.
+// Faults from a file are reloaded, whenever the process receives SIGHUP.
+func newFaultInjector(faults, faultsFile string) (*smarthome.FaultInjector, error) {
+	if faults != "" && faultsFile != "" {
+		return nil, fmt.Errorf("only one of -faults and -faults-file may be set")
+	}
+
+	var config smarthome.FaultConfig
+	if faults != "" {
+		c, err := smarthome.ParseFaultConfig([]byte(faults))
+		if err != nil {
+			return nil, err
+		}
+		config = c
+	}
+	faultInjector, err := smarthome.NewFaultInjector(config)
+	if err != nil {
+		return nil, err
+	}
+	if faultsFile == "" {
+		return faultInjector, nil
+	}
+
+	if err := faultInjector.LoadFile(faultsFile); err != nil {
+		return nil, err
+	}
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		log := ctrl.Log.WithName("faults")
+		for range reload {
+			if err := faultInjector.LoadFile(faultsFile); err != nil {
+				log.Error(err, "unable to reload faults", "file", faultsFile)
+				continue
+			}
+			log.Info("reloaded faults", "file", faultsFile)
+		}
+	}()
+	return faultInjector, nil
+}
+
+// newZapLogger returns a logger writing to stderr, logging V levels up to verbosity.
+func newZapLogger(development bool, verbosity int) logr.Logger {
+	return zap.New(func(o *zap.Options) {
+		o.Development = development
+		// logr V levels are negative zap levels
+		level := uberzap.NewAtomicLevelAt(zapcore.Level(-verbosity))
+		o.Level = &level
+	})
+}
+
+// newHTTPServer returns a Runnable serving the handler on the given address,
+// until the manager stops.
+func newHTTPServer(addr string, handler http.Handler) manager.Runnable {
+	return manager.RunnableFunc(func(stop <-chan struct{}) error {
+		server := &http.Server{Addr: addr, Handler: handler}
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- server.ListenAndServe()
+		}()
+
+		select {
+		case err := <-errCh:
+			return err
+		case <-stop:
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			return server.Shutdown(ctx)
+		}
+	})
+}
+
+// runnableGroup is a Manager, which tracks the Runnables added to it,
+// so we can wait for them to return after the manager stopped.
+type runnableGroup struct {
+	manager.Manager
+
+	mu       sync.Mutex
+	stopping bool
+	wg       sync.WaitGroup
+}
+
+func (g *runnableGroup) Add(r manager.Runnable) error {
+	// inject dependencies into r, they are not passed through the wrapper
+	if err := g.Manager.SetFields(r); err != nil {
+		return err
+	}
+	needLeaderElection := true
+	if le, ok := r.(manager.LeaderElectionRunnable); ok {
+		needLeaderElection = le.NeedLeaderElection()
+	}
+
+	return g.Manager.Add(&trackedRunnable{
+		Runnable:           r,
+		needLeaderElection: needLeaderElection,
+		group:              g,
+	})
+}
+
+// track adds a starting Runnable to the group,
+// unless we are already waiting for the group to stop.
+func (g *runnableGroup) track() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.stopping {
+		return false
+	}
+	g.wg.Add(1)
+	return true
+}
+
+// wait waits for all started Runnables to return and reports, whether they did within the timeout.
+// Runnables never started, e.g. without leadership, are not waited for.
+func (g *runnableGroup) wait(timeout time.Duration) bool {
+	g.mu.Lock()
+	g.stopping = true
+	g.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// trackedRunnable is tracked by its group from when it starts until it returns.
+type trackedRunnable struct {
+	manager.Runnable
+	needLeaderElection bool
+	group              *runnableGroup
+}
+
+func (r *trackedRunnable) Start(stop <-chan struct{}) error {
+	if !r.group.track() {
+		// the manager stopped before we got leadership
+		return nil
+	}
+	defer r.group.wg.Done()
+	return r.Runnable.Start(stop)
+}
+
+func (r *trackedRunnable) NeedLeaderElection() bool {
+	return r.needLeaderElection
+}
+
+// splitList splits a comma separated list, dropping empty entries.
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
 }
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// runnableManager is a Manager, which only collects the Runnables added to it.
+type runnableManager struct {
+	manager.Manager
+	runnables []manager.Runnable
+}
+
+func (m *runnableManager) SetFields(interface{}) error { return nil }
+
+func (m *runnableManager) Add(r manager.Runnable) error {
+	m.runnables = append(m.runnables, r)
+	return nil
+}
+
+func TestRunnableGroupWait(t *testing.T) {
+	mgr := &runnableManager{}
+	group := &runnableGroup{Manager: mgr}
+
+	running := make(chan struct{})
+	if err := group.Add(manager.RunnableFunc(func(stop <-chan struct{}) error {
+		close(running)
+		<-stop
+		return nil
+	})); err != nil {
+		t.Fatal(err)
+	}
+	var lateStarted bool
+	if err := group.Add(manager.RunnableFunc(func(stop <-chan struct{}) error {
+		lateStarted = true
+		return nil
+	})); err != nil {
+		t.Fatal(err)
+	}
+	// e.g. waiting for leadership
+	if err := group.Add(manager.RunnableFunc(func(stop <-chan struct{}) error {
+		t.Error("expected the runnable never to be started")
+		return nil
+	})); err != nil {
+		t.Fatal(err)
+	}
+
+	stop := make(chan struct{})
+	returned := make(chan error)
+	go func() {
+		returned <- mgr.runnables[0].Start(stop)
+	}()
+	<-running
+
+	// started runnables are waited for
+	if group.wait(10 * time.Millisecond) {
+		t.Error("expected to time out waiting for the running runnable")
+	}
+	close(stop)
+	if err := <-returned; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// runnables never started are not waited for
+	if !group.wait(time.Second) {
+		t.Error("expected the wait to end, once the started runnables returned")
+	}
+
+	// runnables starting while we wait are not run
+	if err := mgr.runnables[1].Start(make(chan struct{})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lateStarted {
+		t.Error("expected the runnable not to be run after the manager stopped")
+	}
+}
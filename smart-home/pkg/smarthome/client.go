@@ -1,33 +1,174 @@
 package smarthome
 
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ShutterClient controls the shutters of a smart home.
+// Operations on shutters missing from the inventory return a NotFoundError.
+type ShutterClient interface {
+	// Register adds a shutter to the inventory.
+	Register(ctx context.Context, name string) error
+	// List returns all discovered shutters.
+	List(ctx context.Context) ([]Shutter, error)
+	Get(ctx context.Context, name string) (Shutter, error)
+	// Set moves the shutter to the given position, replacing any previous target.
+	Set(ctx context.Context, name string, percentageClosed int) error
+	// SetMotionProfile changes how the shutter physically moves.
+	SetMotionProfile(ctx context.Context, name string, profile MotionProfile) error
+	// Stop halts the shutter at its current position.
+	Stop(ctx context.Context, name string) error
+	// Watch returns a channel of state changes of all shutters.
+	// The channel is closed when the given context is done.
+	// Receivers falling behind may miss events, but not the latest state.
+	Watch(ctx context.Context) (<-chan ShutterEvent, error)
+}
+
+// ShutterEvent is emitted whenever a shutter starts moving, moves or stops.
+type ShutterEvent struct {
+	Shutter Shutter
+}
+
+// LightClient controls the lights of a smart home.
+// Operations on lights missing from the inventory return a NotFoundError.
+type LightClient interface {
+	// Register adds a light to the inventory.
+	Register(ctx context.Context, name string) error
+	// List returns all discovered lights.
+	List(ctx context.Context) ([]Light, error)
+	Get(ctx context.Context, name string) (Light, error)
+	Switch(ctx context.Context, name string, on bool) error
+}
+
+// SensorClient reads the sensors of a smart home, like wind speed.
+// Operations on sensors missing from the inventory return a NotFoundError.
+type SensorClient interface {
+	// Register adds a sensor to the inventory.
+	Register(ctx context.Context, name string) error
+	// List returns all discovered sensors.
+	List(ctx context.Context) ([]Sensor, error)
+	Get(ctx context.Context, name string) (Sensor, error)
+	// Set changes the reading of a simulated sensor.
+	Set(ctx context.Context, name string, value float64) error
+}
+
+// Backend provides access to the devices of a smart home.
+type Backend interface {
+	Shutters() ShutterClient
+	Lights() LightClient
+	Sensors() SensorClient
+	Close() error
+}
+
+// Inventory lists the devices a Backend knows from the start.
+type Inventory struct {
+	Shutters []string
+	Lights   []string
+	Sensors  []string
+}
+
+// BackendOptions configures a Backend.
+type BackendOptions struct {
+	// Inventory is registered when the Backend is created.
+	Inventory Inventory
+	// Store persists the device state, nothing is persisted if nil.
+	Store Store
+	// SnapshotInterval is the interval in which the device state is saved to the Store.
+	// When zero, the state is only saved on Close.
+	SnapshotInterval time.Duration
+	// ErrorHandler is called with errors of background operations, like periodic snapshots.
+	ErrorHandler func(err error)
+	// Clock drives simulated devices, defaults to RealClock.
+	Clock Clock
+	// Faults injects faults into simulated devices, no faults are injected if nil.
+	Faults *FaultInjector
+}
+
+// BackendFactory creates a new Backend instance.
+type BackendFactory func(opts BackendOptions) (Backend, error)
+
+var (
+	backends    = map[string]BackendFactory{}
+	backendsMux sync.Mutex
+)
+
+// RegisterBackend makes a Backend available under the given name.
+// It panics if a backend with the same name is already registered.
+func RegisterBackend(name string, factory BackendFactory) {
+	backendsMux.Lock()
+	defer backendsMux.Unlock()
+
+	if _, ok := backends[name]; ok {
+		panic(fmt.Sprintf("smarthome: backend %q registered twice", name))
+	}
+	backends[name] = factory
+}
+
+// Backends returns the sorted names of all registered backends.
+func Backends() []string {
+	backendsMux.Lock()
+	defer backendsMux.Unlock()
+
+	var names []string
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 type Client struct {
-	shutterClient *ShutterClient
-	lightClient   *LightClient
+	backend Backend
 }
 
-func NewClient() *Client {
-	c := &Client{
-		shutterClient: newShutterClient(),
-		lightClient:   newLightClient(),
+// NewClient creates a new Client using the backend registered under the given name.
+func NewClient(backend string, opts BackendOptions) (*Client, error) {
+	backendsMux.Lock()
+	factory, ok := backends[backend]
+	backendsMux.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown backend %q", backend)
 	}
-	return c
+
+	b, err := factory(opts)
+	if err != nil {
+		return nil, fmt.Errorf("creating backend %q: %w", backend, err)
+	}
+	return &Client{backend: b}, nil
+}
+
+func (c *Client) Shutters() ShutterClient {
+	return c.backend.Shutters()
 }
 
-func (c *Client) Shutters() *ShutterClient {
-	return c.shutterClient
+func (c *Client) Lights() LightClient {
+	return c.backend.Lights()
 }
 
-func (c *Client) Lights() *LightClient {
-	return c.lightClient
+func (c *Client) Sensors() SensorClient {
+	return c.backend.Sensors()
 }
 
-func (c *Client) Close() {
-	c.shutterClient.close()
-	c.lightClient.close()
+// Close shuts down the backend and persists the device state.
+func (c *Client) Close() error {
+	return c.backend.Close()
 }
 
+// ValidationError is returned for invalid requests.
+// It is terminal: retrying the same request will fail again.
 type ValidationError string
 
 func (e ValidationError) Error() string {
 	return string(e)
 }
+
+// NotFoundError is returned for devices missing from the inventory.
+type NotFoundError string
+
+func (e NotFoundError) Error() string {
+	return string(e)
+}
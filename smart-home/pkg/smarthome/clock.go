@@ -0,0 +1,132 @@
+package smarthome
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock provides the current time and tickers,
+// so the passing of time can be controlled in tests.
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers ticks in regular intervals, see time.Ticker.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// RealClock is the Clock of the real world.
+var RealClock Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTicker struct {
+	*time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
+
+// FakeClock is a Clock that only moves forward when Step is called.
+type FakeClock struct {
+	mux     sync.Mutex
+	changed *sync.Cond
+	now     time.Time
+	tickers map[*fakeTicker]struct{}
+}
+
+var _ Clock = (*FakeClock)(nil)
+
+// NewFakeClock creates a FakeClock starting at the given time.
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{
+		now:     now,
+		tickers: map[*fakeTicker]struct{}{},
+	}
+	c.changed = sync.NewCond(&c.mux)
+	return c
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for FakeClock.NewTicker")
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	t := &fakeTicker{
+		clock:    c,
+		c:        make(chan time.Time, 1),
+		interval: d,
+		next:     c.now.Add(d),
+	}
+	c.tickers[t] = struct{}{}
+	c.changed.Broadcast()
+	return t
+}
+
+// Step moves the clock forward, firing all tickers that are due.
+// Like time.Ticker, ticks are dropped for slow receivers.
+func (c *FakeClock) Step(d time.Duration) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.now = c.now.Add(d)
+	for t := range c.tickers {
+		for !t.next.After(c.now) {
+			select {
+			case t.c <- t.next:
+			default:
+			}
+			t.next = t.next.Add(t.interval)
+		}
+	}
+}
+
+// BlockUntil blocks until exactly n tickers are running.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	for len(c.tickers) != n {
+		c.changed.Wait()
+	}
+}
+
+type fakeTicker struct {
+	clock    *FakeClock
+	c        chan time.Time
+	interval time.Duration
+	next     time.Time
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.mux.Lock()
+	defer t.clock.mux.Unlock()
+
+	delete(t.clock.tickers, t)
+	t.clock.changed.Broadcast()
+}
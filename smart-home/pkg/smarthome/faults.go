@@ -0,0 +1,249 @@
+package smarthome
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Operations faults can be injected into.
+const (
+	OpList             = "list"
+	OpGet              = "get"
+	OpSet              = "set"
+	OpStop             = "stop"
+	OpSetMotionProfile = "setMotionProfile"
+	OpSwitch           = "switch"
+)
+
+// FaultConfig describes the faults injected into simulated devices.
+type FaultConfig struct {
+	// FailureRates is the probability between 0 and 1 of an operation failing, by operation name.
+	FailureRates map[string]float64 `json:"failureRates,omitempty"`
+	// Latency is added to every operation.
+	Latency Duration `json:"latency,omitempty"`
+	// Unreachable devices fail every operation.
+	Unreachable []string `json:"unreachable,omitempty"`
+	// StuckAt makes the motor of a shutter get stuck,
+	// when it reaches the given closed percentage.
+	StuckAt map[string]int `json:"stuckAt,omitempty"`
+	// SensorDrift is added to the reported closed percentage of a shutter.
+	SensorDrift map[string]int `json:"sensorDrift,omitempty"`
+}
+
+// Validate checks the FaultConfig for invalid values.
+func (c FaultConfig) Validate() error {
+	for op, rate := range c.FailureRates {
+		if rate < 0 || rate > 1 {
+			return ValidationError(fmt.Sprintf("failure rate of %q must be between 0 and 1", op))
+		}
+	}
+	if c.Latency.Duration < 0 {
+		return ValidationError("latency must not be negative")
+	}
+	for name, p := range c.StuckAt {
+		if p < 0 || p > 100 {
+			return ValidationError(fmt.Sprintf("stuck position of %q must be between 0 and 100", name))
+		}
+	}
+	for name, drift := range c.SensorDrift {
+		if drift < -100 || drift > 100 {
+			return ValidationError(fmt.Sprintf("sensor drift of %q must be between -100 and 100", name))
+		}
+	}
+	return nil
+}
+
+// DeepCopy returns a copy of the FaultConfig, not sharing any maps or slices.
+func (c FaultConfig) DeepCopy() FaultConfig {
+	out := c
+	if c.FailureRates != nil {
+		out.FailureRates = make(map[string]float64, len(c.FailureRates))
+		for k, v := range c.FailureRates {
+			out.FailureRates[k] = v
+		}
+	}
+	if c.Unreachable != nil {
+		out.Unreachable = make([]string, len(c.Unreachable))
+		copy(out.Unreachable, c.Unreachable)
+	}
+	out.StuckAt = copyIntMap(c.StuckAt)
+	out.SensorDrift = copyIntMap(c.SensorDrift)
+	return out
+}
+
+func copyIntMap(in map[string]int) map[string]int {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string]int, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
+
+// Duration is a time.Duration encoded as string in JSON, e.g. "150ms".
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	duration, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = duration
+	return nil
+}
+
+// FaultError is returned by operations failing because of an injected fault.
+type FaultError string
+
+func (e FaultError) Error() string {
+	return string(e)
+}
+
+// FaultInjector injects faults into simulated devices.
+// The FaultConfig can be changed at any time.
+// A nil FaultInjector injects no faults.
+type FaultInjector struct {
+	config FaultConfig
+	mux    sync.RWMutex
+}
+
+// NewFaultInjector creates a FaultInjector with the given FaultConfig.
+func NewFaultInjector(config FaultConfig) (*FaultInjector, error) {
+	f := &FaultInjector{}
+	if err := f.SetConfig(config); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Config returns a copy of the current FaultConfig.
+func (f *FaultInjector) Config() FaultConfig {
+	f.mux.RLock()
+	defer f.mux.RUnlock()
+	return f.config.DeepCopy()
+}
+
+// SetConfig replaces the FaultConfig with a copy of the given one.
+func (f *FaultInjector) SetConfig(config FaultConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	config = config.DeepCopy()
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.config = config
+	return nil
+}
+
+// LoadFile replaces the FaultConfig with the JSON encoded FaultConfig in the given file.
+func (f *FaultInjector) LoadFile(path string) error {
+	js, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading fault config: %w", err)
+	}
+
+	config, err := ParseFaultConfig(js)
+	if err != nil {
+		return err
+	}
+	return f.SetConfig(config)
+}
+
+// ParseFaultConfig decodes a JSON encoded FaultConfig.
+func ParseFaultConfig(js []byte) (FaultConfig, error) {
+	var config FaultConfig
+	if err := json.Unmarshal(js, &config); err != nil {
+		return config, fmt.Errorf("decoding fault config: %w", err)
+	}
+	return config, nil
+}
+
+// inject is called before every operation on a device,
+// returning an error if the operation should fail.
+func (f *FaultInjector) inject(ctx context.Context, op, device string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if f == nil {
+		return nil
+	}
+
+	f.mux.RLock()
+	latency := f.config.Latency.Duration
+	var unreachable bool
+	for _, name := range f.config.Unreachable {
+		if device != "" && name == device {
+			unreachable = true
+		}
+	}
+	rate := f.config.FailureRates[op]
+	f.mux.RUnlock()
+
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	if unreachable {
+		return FaultError(fmt.Sprintf("device %q is unreachable", device))
+	}
+	if rate > 0 && rand.Float64() < rate {
+		return FaultError(fmt.Sprintf("%s failed", op))
+	}
+	return nil
+}
+
+// stuckAt returns the position the motor of the given shutter is stuck at.
+func (f *FaultInjector) stuckAt(shutter string) (int, bool) {
+	if f == nil {
+		return 0, false
+	}
+
+	f.mux.RLock()
+	defer f.mux.RUnlock()
+	p, ok := f.config.StuckAt[shutter]
+	return p, ok
+}
+
+// drift applies the sensor drift of the given shutter to the reported state.
+func (f *FaultInjector) drift(shutter Shutter) Shutter {
+	if f == nil {
+		return shutter
+	}
+
+	f.mux.RLock()
+	drift := f.config.SensorDrift[shutter.Name]
+	f.mux.RUnlock()
+
+	shutter.Current += drift
+	if shutter.Current < 0 {
+		shutter.Current = 0
+	}
+	if shutter.Current > 100 {
+		shutter.Current = 100
+	}
+	return shutter
+}
@@ -0,0 +1,156 @@
+package smarthome
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFaultInjector(t *testing.T) {
+	faults, err := NewFaultInjector(FaultConfig{
+		FailureRates: map[string]float64{OpSwitch: 1},
+		Unreachable:  []string{"attic"},
+		SensorDrift:  map[string]int{"kitchen": -5},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error calling NewFaultInjector: %v", err)
+	}
+	ctx := context.Background()
+
+	lights := newLightSimulator(faults)
+	if err := lights.Register(ctx, "kitchen"); err != nil {
+		t.Fatalf("unexpected error calling .Register: %v", err)
+	}
+	if err := lights.Switch(ctx, "kitchen", true); err == nil {
+		t.Error("expected switch to fail")
+	} else if _, ok := err.(FaultError); !ok {
+		t.Errorf("expected FaultError, got: %v", err)
+	}
+	if _, err := lights.Get(ctx, "kitchen"); err != nil {
+		t.Errorf("unexpected error calling .Get: %v", err)
+	}
+
+	shutters := newShutterSimulator(NewFakeClock(time.Unix(0, 0)), faults)
+	defer shutters.close()
+	if _, err := shutters.Get(ctx, "attic"); err == nil {
+		t.Error("expected unreachable shutter to fail")
+	}
+	if err := shutters.Register(ctx, "kitchen"); err != nil {
+		t.Fatalf("unexpected error calling .Register: %v", err)
+	}
+	if err := shutters.restore([]Shutter{{Name: "kitchen", Current: 2}}); err != nil {
+		t.Fatalf("unexpected error calling .restore: %v", err)
+	}
+	if s, _ := shutters.Get(ctx, "kitchen"); s.Current != 0 {
+		t.Errorf("expected drifted position to be 0%%, is: %d%%", s.Current)
+	}
+
+	// faults can be changed at runtime
+	if err := faults.SetConfig(FaultConfig{}); err != nil {
+		t.Fatalf("unexpected error calling .SetConfig: %v", err)
+	}
+	if err := lights.Switch(ctx, "kitchen", true); err != nil {
+		t.Errorf("unexpected error calling .Switch: %v", err)
+	}
+	if s, _ := shutters.Get(ctx, "kitchen"); s.Current != 2 {
+		t.Errorf("expected position to be 2%%, is: %d%%", s.Current)
+	}
+}
+
+func TestShutterStuck(t *testing.T) {
+	faults, err := NewFaultInjector(FaultConfig{
+		StuckAt: map[string]int{"test": 15},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error calling NewFaultInjector: %v", err)
+	}
+
+	s, clock, events := newTestShutter(0)
+	s.faults = faults
+	defer s.close()
+
+	if err := s.Set(50); err != nil {
+		t.Fatalf("unexpected error calling .Set: %v", err)
+	}
+	expectState(t, events, Shutter{Name: "test", Current: 0, Target: 50, Moving: true})
+
+	clock.BlockUntil(1)
+	clock.Step(time.Second)
+	expectState(t, events, Shutter{Name: "test", Current: 10, Target: 50, Moving: true})
+
+	clock.Step(time.Second)
+	expectState(t, events, Shutter{Name: "test", Current: 15, Target: 50, Moving: true})
+	expectState(t, events, Shutter{Name: "test", Current: 15, Target: 50})
+}
+
+func TestShutterStuckSetAgain(t *testing.T) {
+	faults, err := NewFaultInjector(FaultConfig{
+		StuckAt: map[string]int{"test": 15},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error calling NewFaultInjector: %v", err)
+	}
+
+	s, clock, events := newTestShutter(10)
+	s.faults = faults
+	defer s.close()
+
+	if err := s.Set(50); err != nil {
+		t.Fatalf("unexpected error calling .Set: %v", err)
+	}
+	expectState(t, events, Shutter{Name: "test", Current: 10, Target: 50, Moving: true})
+	clock.BlockUntil(1)
+	clock.Step(time.Second)
+	expectState(t, events, Shutter{Name: "test", Current: 15, Target: 50, Moving: true})
+	expectState(t, events, Shutter{Name: "test", Current: 15, Target: 50})
+
+	// setting the same target does not restart the motor of the stuck shutter
+	for i := 0; i < 2; i++ {
+		if err := s.Set(50); err != nil {
+			t.Fatalf("unexpected error calling .Set: %v", err)
+		}
+	}
+	select {
+	case state := <-events:
+		t.Fatalf("unexpected shutter state %+v", state)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// a new target tries again
+	if err := s.Set(0); err != nil {
+		t.Fatalf("unexpected error calling .Set: %v", err)
+	}
+	expectState(t, events, Shutter{Name: "test", Current: 15, Target: 0, Moving: true})
+}
+
+func TestFaultInjectorConfigCopy(t *testing.T) {
+	config := FaultConfig{
+		FailureRates: map[string]float64{OpSet: 0.5},
+		Unreachable:  []string{"kitchen"},
+		StuckAt:      map[string]int{"kitchen": 15},
+		SensorDrift:  map[string]int{"kitchen": 2},
+	}
+	faults, err := NewFaultInjector(config)
+	if err != nil {
+		t.Fatalf("unexpected error calling NewFaultInjector: %v", err)
+	}
+
+	// neither the config passed in, nor the config returned changes the injector
+	config.FailureRates[OpSet] = 1
+	config.Unreachable[0] = "bedroom"
+	config.StuckAt["kitchen"] = 50
+	got := faults.Config()
+	got.SensorDrift["kitchen"] = 10
+	got.StuckAt["bedroom"] = 20
+
+	expected := FaultConfig{
+		FailureRates: map[string]float64{OpSet: 0.5},
+		Unreachable:  []string{"kitchen"},
+		StuckAt:      map[string]int{"kitchen": 15},
+		SensorDrift:  map[string]int{"kitchen": 2},
+	}
+	if got := faults.Config(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected config %+v, got %+v", expected, got)
+	}
+}
@@ -2,8 +2,7 @@ package smarthome
 
 import (
 	"context"
-	"encoding/json"
-	"io/ioutil"
+	"fmt"
 	"sort"
 	"sync"
 )
@@ -13,33 +12,45 @@ type Light struct {
 	On   bool
 }
 
-type LightClient struct {
+// lightSimulator is a LightClient simulating lights in memory.
+type lightSimulator struct {
 	data    map[string]*Light
 	dataMux sync.Mutex
+
+	faults *FaultInjector
 }
 
-func newLightClient() *LightClient {
-	lc := &LightClient{
-		data: map[string]*Light{},
+func newLightSimulator(faults *FaultInjector) *lightSimulator {
+	return &lightSimulator{
+		data:   map[string]*Light{},
+		faults: faults,
 	}
-	js, _ := ioutil.ReadFile("/tmp/godays2020/lights.json")
-	var lights []Light
-	_ = json.Unmarshal(js, &lights)
+}
+
+// restore loads the state of the given lights from a snapshot.
+// Lights not in the inventory are dropped.
+func (lc *lightSimulator) restore(lights []Light) {
+	lc.dataMux.Lock()
+	defer lc.dataMux.Unlock()
 
 	for _, light := range lights {
-		l := lc.getLight(light.Name)
+		l, ok := lc.data[light.Name]
+		if !ok {
+			continue
+		}
 		l.On = light.On
 	}
-	return lc
 }
 
-func (lc *LightClient) close() {
-	lights, _ := lc.List(nil)
-	js, _ := json.Marshal(lights)
-	_ = ioutil.WriteFile("/tmp/godays2020/lights.json", js, 0700)
+func (lc *lightSimulator) getLight(name string) (*Light, error) {
+	if l, ok := lc.data[name]; ok {
+		return l, nil
+	}
+	return nil, NotFoundError(fmt.Sprintf("light %q not found", name))
 }
 
-func (lc *LightClient) getLight(name string) *Light {
+// addLight adds a new light to the inventory, if it does not exist yet.
+func (lc *lightSimulator) addLight(name string) *Light {
 	if l, ok := lc.data[name]; ok {
 		return l
 	}
@@ -47,24 +58,58 @@ func (lc *LightClient) getLight(name string) *Light {
 	return lc.data[name]
 }
 
-func (lc *LightClient) Switch(ctx context.Context, name string, on bool) error {
+func (lc *lightSimulator) Register(ctx context.Context, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	lc.dataMux.Lock()
+	defer lc.dataMux.Unlock()
+
+	lc.addLight(name)
+	return nil
+}
+
+func (lc *lightSimulator) Switch(ctx context.Context, name string, on bool) error {
+	if err := lc.faults.inject(ctx, OpSwitch, name); err != nil {
+		return err
+	}
+
 	lc.dataMux.Lock()
 	defer lc.dataMux.Unlock()
 
-	light := lc.getLight(name)
+	light, err := lc.getLight(name)
+	if err != nil {
+		return err
+	}
 	light.On = on
 	return nil
 }
 
-func (lc *LightClient) Get(ctx context.Context, name string) (Light, error) {
+func (lc *lightSimulator) Get(ctx context.Context, name string) (Light, error) {
+	if err := lc.faults.inject(ctx, OpGet, name); err != nil {
+		return Light{}, err
+	}
+
 	lc.dataMux.Lock()
 	defer lc.dataMux.Unlock()
 
-	light := lc.getLight(name)
+	light, err := lc.getLight(name)
+	if err != nil {
+		return Light{}, err
+	}
 	return *light, nil
 }
 
-func (lc *LightClient) List(ctx context.Context) ([]Light, error) {
+func (lc *lightSimulator) List(ctx context.Context) ([]Light, error) {
+	if err := lc.faults.inject(ctx, OpList, ""); err != nil {
+		return nil, err
+	}
+	return lc.snapshot(), nil
+}
+
+// snapshot returns the actual state of all lights, not affected by faults.
+func (lc *lightSimulator) snapshot() []Light {
 	lc.dataMux.Lock()
 	defer lc.dataMux.Unlock()
 
@@ -74,7 +119,7 @@ func (lc *LightClient) List(ctx context.Context) ([]Light, error) {
 	}
 
 	sort.Sort(lights)
-	return lights, nil
+	return lights
 }
 
 // lightsByName sorts Lights by name
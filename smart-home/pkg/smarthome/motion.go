@@ -0,0 +1,63 @@
+package smarthome
+
+import (
+	"time"
+)
+
+// MotionProfile describes the physical movement of a shutter.
+type MotionProfile struct {
+	// CloseTime is the time a full close from 0% to 100% takes at full speed.
+	CloseTime time.Duration
+	// OpenTime is the time a full open from 100% to 0% takes at full speed.
+	OpenTime time.Duration
+	// Acceleration is the time the motor needs to reach full speed.
+	Acceleration time.Duration
+	// DeadTime is the delay between starting the motor and the shutter starting to move.
+	DeadTime time.Duration
+}
+
+// DefaultMotionProfile moves a shutter roughly 9% per second in both directions,
+// as moving more than 10% per second would destroy the shutter ... and the window.
+var DefaultMotionProfile = MotionProfile{
+	CloseTime: 11 * time.Second,
+	OpenTime:  11 * time.Second,
+}
+
+// Validate checks the MotionProfile for physically impossible values.
+func (p MotionProfile) Validate() error {
+	if p.CloseTime <= 0 {
+		return ValidationError("close time must be positive")
+	}
+	if p.OpenTime <= 0 {
+		return ValidationError("open time must be positive")
+	}
+	if p.Acceleration < 0 {
+		return ValidationError("acceleration must not be negative")
+	}
+	if p.DeadTime < 0 {
+		return ValidationError("dead time must not be negative")
+	}
+	return nil
+}
+
+// distance returns how far in percent a shutter has travelled,
+// the given time after its motor started moving in the given direction.
+func (p MotionProfile) distance(elapsed time.Duration, closing bool) float64 {
+	travelTime := p.OpenTime
+	if closing {
+		travelTime = p.CloseTime
+	}
+	speed := 100 / travelTime.Seconds() // percent per second
+
+	t := (elapsed - p.DeadTime).Seconds()
+	if t <= 0 {
+		return 0
+	}
+
+	// speed increases linearly, until full speed is reached
+	acceleration := p.Acceleration.Seconds()
+	if t < acceleration {
+		return speed / acceleration * t * t / 2
+	}
+	return speed*acceleration/2 + speed*(t-acceleration)
+}
@@ -0,0 +1,56 @@
+package smarthome
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMotionProfileDistance(t *testing.T) {
+	profile := MotionProfile{
+		CloseTime:    10 * time.Second,
+		OpenTime:     20 * time.Second,
+		Acceleration: 2 * time.Second,
+		DeadTime:     500 * time.Millisecond,
+	}
+
+	tests := []struct {
+		Name     string
+		Elapsed  time.Duration
+		Closing  bool
+		Distance float64
+	}{
+		{
+			Name:    "during dead time",
+			Elapsed: 400 * time.Millisecond, Closing: true,
+			Distance: 0,
+		},
+		{
+			Name:    "while accelerating",
+			Elapsed: 1500 * time.Millisecond, Closing: true,
+			// 10%/s reached after 2s: 10/2 * 1^2 / 2
+			Distance: 2.5,
+		},
+		{
+			Name:    "at full speed closing",
+			Elapsed: 4500 * time.Millisecond, Closing: true,
+			// 10% during acceleration + 2s at 10%/s
+			Distance: 30,
+		},
+		{
+			Name:    "at full speed opening",
+			Elapsed: 4500 * time.Millisecond, Closing: false,
+			// 5% during acceleration + 2s at 5%/s
+			Distance: 15,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			d := profile.distance(test.Elapsed, test.Closing)
+			if math.Abs(d-test.Distance) > 1e-9 {
+				t.Errorf("expected distance %v, got %v", test.Distance, d)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package smarthome
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// sensorReading is the JSON representation of a Sensor in the sensor API.
+type sensorReading struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+}
+
+// NewSensorAPI returns a HTTP handler to read and set sensors, e.g. to simulate wind:
+//
+//	GET /sensors               lists all sensors
+//	GET /sensors/{name}        returns a single sensor
+//	PUT /sensors/{name}        sets a sensor from a body like {"value": 80}
+func NewSensorAPI(sensors SensorClient) http.Handler {
+	return &sensorAPI{sensors: sensors}
+}
+
+type sensorAPI struct {
+	sensors SensorClient
+}
+
+func (a *sensorAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+	if path == "sensors" {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		a.list(w, r)
+		return
+	}
+
+	name := strings.TrimPrefix(path, "sensors/")
+	if name == path || name == "" {
+		http.NotFound(w, r)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		a.get(w, r, name)
+	case http.MethodPut:
+		a.set(w, r, name)
+	default:
+		w.Header().Set("Allow", http.MethodGet+", "+http.MethodPut)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (a *sensorAPI) list(w http.ResponseWriter, r *http.Request) {
+	sensors, err := a.sensors.List(r.Context())
+	if err != nil {
+		writeSensorError(w, err)
+		return
+	}
+
+	readings := make([]sensorReading, len(sensors))
+	for i, sensor := range sensors {
+		readings[i] = sensorReading{Name: sensor.Name, Value: sensor.Value}
+	}
+	writeJSON(w, readings)
+}
+
+func (a *sensorAPI) get(w http.ResponseWriter, r *http.Request, name string) {
+	sensor, err := a.sensors.Get(r.Context(), name)
+	if err != nil {
+		writeSensorError(w, err)
+		return
+	}
+	writeJSON(w, sensorReading{Name: sensor.Name, Value: sensor.Value})
+}
+
+func (a *sensorAPI) set(w http.ResponseWriter, r *http.Request, name string) {
+	var reading sensorReading
+	if err := json.NewDecoder(r.Body).Decode(&reading); err != nil {
+		http.Error(w, "decoding sensor reading: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := a.sensors.Set(r.Context(), name, reading.Value); err != nil {
+		writeSensorError(w, err)
+		return
+	}
+	a.get(w, r, name)
+}
+
+func writeSensorError(w http.ResponseWriter, err error) {
+	var (
+		notFound   NotFoundError
+		validation ValidationError
+	)
+	switch {
+	case errors.As(err, &notFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.As(err, &validation):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
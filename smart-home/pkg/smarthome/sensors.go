@@ -0,0 +1,126 @@
+package smarthome
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Sensor is a reading of a smart home sensor, e.g. the wind speed in km/h.
+type Sensor struct {
+	Name  string
+	Value float64
+}
+
+// sensorSimulator is a SensorClient simulating sensors in memory.
+type sensorSimulator struct {
+	data    map[string]*Sensor
+	dataMux sync.Mutex
+
+	faults *FaultInjector
+}
+
+func newSensorSimulator(faults *FaultInjector) *sensorSimulator {
+	return &sensorSimulator{
+		data:   map[string]*Sensor{},
+		faults: faults,
+	}
+}
+
+// restore loads the readings of the given sensors from a snapshot.
+// Sensors not in the inventory are dropped.
+func (sc *sensorSimulator) restore(sensors []Sensor) {
+	sc.dataMux.Lock()
+	defer sc.dataMux.Unlock()
+
+	for _, sensor := range sensors {
+		s, ok := sc.data[sensor.Name]
+		if !ok {
+			continue
+		}
+		s.Value = sensor.Value
+	}
+}
+
+func (sc *sensorSimulator) getSensor(name string) (*Sensor, error) {
+	if s, ok := sc.data[name]; ok {
+		return s, nil
+	}
+	return nil, NotFoundError(fmt.Sprintf("sensor %q not found", name))
+}
+
+// addSensor adds a new sensor to the inventory, if it does not exist yet.
+func (sc *sensorSimulator) addSensor(name string) *Sensor {
+	if s, ok := sc.data[name]; ok {
+		return s
+	}
+	sc.data[name] = &Sensor{Name: name}
+	return sc.data[name]
+}
+
+func (sc *sensorSimulator) Register(ctx context.Context, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	sc.dataMux.Lock()
+	defer sc.dataMux.Unlock()
+
+	sc.addSensor(name)
+	return nil
+}
+
+func (sc *sensorSimulator) Set(ctx context.Context, name string, value float64) error {
+	if err := sc.faults.inject(ctx, OpSet, name); err != nil {
+		return err
+	}
+
+	sc.dataMux.Lock()
+	defer sc.dataMux.Unlock()
+
+	sensor, err := sc.getSensor(name)
+	if err != nil {
+		return err
+	}
+	sensor.Value = value
+	return nil
+}
+
+func (sc *sensorSimulator) Get(ctx context.Context, name string) (Sensor, error) {
+	if err := sc.faults.inject(ctx, OpGet, name); err != nil {
+		return Sensor{}, err
+	}
+
+	sc.dataMux.Lock()
+	defer sc.dataMux.Unlock()
+
+	sensor, err := sc.getSensor(name)
+	if err != nil {
+		return Sensor{}, err
+	}
+	return *sensor, nil
+}
+
+func (sc *sensorSimulator) List(ctx context.Context) ([]Sensor, error) {
+	if err := sc.faults.inject(ctx, OpList, ""); err != nil {
+		return nil, err
+	}
+	return sc.snapshot(), nil
+}
+
+// snapshot returns the actual readings of all sensors, not affected by faults.
+func (sc *sensorSimulator) snapshot() []Sensor {
+	sc.dataMux.Lock()
+	defer sc.dataMux.Unlock()
+
+	sensors := make([]Sensor, 0, len(sc.data))
+	for _, sensor := range sc.data {
+		sensors = append(sensors, *sensor)
+	}
+
+	sort.Slice(sensors, func(i, j int) bool {
+		return sensors[i].Name < sensors[j].Name
+	})
+	return sensors
+}
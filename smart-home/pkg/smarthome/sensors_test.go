@@ -0,0 +1,76 @@
+package smarthome
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSensorAPI(t *testing.T) {
+	sc := newSensorSimulator(nil)
+	if err := sc.Register(context.Background(), "wind-speed"); err != nil {
+		t.Fatal(err)
+	}
+	api := NewSensorAPI(sc)
+
+	tests := []struct {
+		name           string
+		method, path   string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:   "set sensor",
+			method: http.MethodPut, path: "/sensors/wind-speed",
+			body:           `{"value": 80}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"name":"wind-speed","value":80}`,
+		},
+		{
+			name:   "get sensor",
+			method: http.MethodGet, path: "/sensors/wind-speed",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"name":"wind-speed","value":80}`,
+		},
+		{
+			name:   "list sensors",
+			method: http.MethodGet, path: "/sensors",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[{"name":"wind-speed","value":80}]`,
+		},
+		{
+			name:   "unknown sensor",
+			method: http.MethodPut, path: "/sensors/typo",
+			body:           `{"value": 80}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "invalid body",
+			method: http.MethodPut, path: "/sensors/wind-speed",
+			body:           `80 km/h`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "unsupported method",
+			method: http.MethodDelete, path: "/sensors/wind-speed",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			api.ServeHTTP(rec, httptest.NewRequest(test.method, test.path, strings.NewReader(test.body)))
+
+			if rec.Code != test.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", test.expectedStatus, rec.Code, rec.Body)
+			}
+			if body := strings.TrimSpace(rec.Body.String()); test.expectedBody != "" && body != test.expectedBody {
+				t.Errorf("expected body %s, got %s", test.expectedBody, body)
+			}
+		})
+	}
+}
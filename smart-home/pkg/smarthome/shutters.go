@@ -2,8 +2,8 @@ package smarthome
 
 import (
 	"context"
-	"encoding/json"
-	"io/ioutil"
+	"fmt"
+	"math"
 	"sort"
 	"sync"
 	"time"
@@ -15,38 +15,59 @@ type Shutter struct {
 	Moving          bool
 }
 
-type ShutterClient struct {
+// shutterSimulator is a ShutterClient simulating shutters in memory.
+type shutterSimulator struct {
 	data    map[string]*shutter
 	dataMux sync.Mutex
-}
 
-func newShutterClient() *ShutterClient {
-	sc := &ShutterClient{
-		data: map[string]*shutter{},
-	}
+	watchers    map[*shutterWatcher]struct{}
+	watchersMux sync.RWMutex
 
-	js, _ := ioutil.ReadFile("/tmp/godays2020/shutters.json")
-	var shutters []Shutter
-	_ = json.Unmarshal(js, &shutters)
+	clock  Clock
+	faults *FaultInjector
+}
 
-	for _, shutter := range shutters {
-		s := sc.getShutter(shutter.Name)
-		s.closedPercentage = shutter.Current
+type shutterWatcher struct {
+	events chan ShutterEvent
+}
+
+func newShutterSimulator(clock Clock, faults *FaultInjector) *shutterSimulator {
+	sc := &shutterSimulator{
+		data:     map[string]*shutter{},
+		watchers: map[*shutterWatcher]struct{}{},
+		clock:    clock,
+		faults:   faults,
 	}
 	return sc
 }
 
-func (sc *ShutterClient) close() {
-	shutters, _ := sc.List(nil)
-	js, _ := json.Marshal(shutters)
-	_ = ioutil.WriteFile("/tmp/godays2020/shutters.json", js, 0700)
+// restore loads the state of the given shutters from a snapshot.
+// Shutters that were moving when the snapshot was taken resume moving towards their target.
+// Shutters not in the inventory are dropped.
+func (sc *shutterSimulator) restore(shutters []Shutter) error {
+	sc.dataMux.Lock()
+	defer sc.dataMux.Unlock()
 
-	for _, shutter := range sc.data {
-		shutter.close()
+	for _, shutter := range shutters {
+		s, ok := sc.data[shutter.Name]
+		if !ok {
+			continue
+		}
+		s.position = float64(shutter.Current)
+		s.targetPercentage = shutter.Current
+		if !shutter.Moving || shutter.Target == shutter.Current {
+			continue
+		}
+
+		if err := s.Set(shutter.Target); err != nil {
+			return fmt.Errorf("resuming shutter %q: %w", shutter.Name, err)
+		}
 	}
+	return nil
 }
 
-func (sc *ShutterClient) List(ctx context.Context) ([]Shutter, error) {
+// snapshot returns the actual state of all shutters, not affected by faults.
+func (sc *shutterSimulator) snapshot() []Shutter {
 	sc.dataMux.Lock()
 	defer sc.dataMux.Unlock()
 
@@ -56,30 +77,169 @@ func (sc *ShutterClient) List(ctx context.Context) ([]Shutter, error) {
 	}
 
 	sort.Sort(shutters)
+	return shutters
+}
+
+func (sc *shutterSimulator) close() {
+	sc.dataMux.Lock()
+	defer sc.dataMux.Unlock()
+
+	for _, shutter := range sc.data {
+		shutter.close()
+	}
+}
+
+func (sc *shutterSimulator) List(ctx context.Context) ([]Shutter, error) {
+	if err := sc.faults.inject(ctx, OpList, ""); err != nil {
+		return nil, err
+	}
+
+	shutters := sc.snapshot()
+	for i := range shutters {
+		shutters[i] = sc.faults.drift(shutters[i])
+	}
 	return shutters, nil
 }
 
-func (sc *ShutterClient) Get(ctx context.Context, name string) (Shutter, error) {
+func (sc *shutterSimulator) Get(ctx context.Context, name string) (Shutter, error) {
+	if err := sc.faults.inject(ctx, OpGet, name); err != nil {
+		return Shutter{}, err
+	}
+
+	sc.dataMux.Lock()
+	defer sc.dataMux.Unlock()
+
+	s, err := sc.getShutter(name)
+	if err != nil {
+		return Shutter{}, err
+	}
+	return sc.faults.drift(s.Shutter()), nil
+}
+
+func (sc *shutterSimulator) Set(ctx context.Context, name string, percentageClosed int) error {
+	if err := sc.faults.inject(ctx, OpSet, name); err != nil {
+		return err
+	}
+
+	sc.dataMux.Lock()
+	defer sc.dataMux.Unlock()
+
+	s, err := sc.getShutter(name)
+	if err != nil {
+		return err
+	}
+	return s.Set(percentageClosed)
+}
+
+func (sc *shutterSimulator) SetMotionProfile(ctx context.Context, name string, profile MotionProfile) error {
+	if err := sc.faults.inject(ctx, OpSetMotionProfile, name); err != nil {
+		return err
+	}
+
 	sc.dataMux.Lock()
 	defer sc.dataMux.Unlock()
 
-	shutter := sc.getShutter(name).Shutter()
-	return shutter, nil
+	s, err := sc.getShutter(name)
+	if err != nil {
+		return err
+	}
+	return s.SetMotionProfile(profile)
 }
 
-func (sc *ShutterClient) Set(ctx context.Context, name string, percentageClosed int) error {
+func (sc *shutterSimulator) Stop(ctx context.Context, name string) error {
+	if err := sc.faults.inject(ctx, OpStop, name); err != nil {
+		return err
+	}
+
 	sc.dataMux.Lock()
 	defer sc.dataMux.Unlock()
 
-	return sc.getShutter(name).Set(percentageClosed)
+	s, err := sc.getShutter(name)
+	if err != nil {
+		return err
+	}
+	s.Stop()
+	return nil
+}
+
+func (sc *shutterSimulator) Register(ctx context.Context, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	sc.dataMux.Lock()
+	defer sc.dataMux.Unlock()
+
+	sc.addShutter(name)
+	return nil
 }
 
-func (sc *ShutterClient) getShutter(name string) *shutter {
+func (sc *shutterSimulator) Watch(ctx context.Context) (<-chan ShutterEvent, error) {
+	w := &shutterWatcher{
+		events: make(chan ShutterEvent, 100),
+	}
+
+	sc.watchersMux.Lock()
+	sc.watchers[w] = struct{}{}
+	sc.watchersMux.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		sc.watchersMux.Lock()
+		defer sc.watchersMux.Unlock()
+		delete(sc.watchers, w)
+		close(w.events)
+	}()
+	return w.events, nil
+}
+
+// emit sends the given Shutter state to all watchers.
+// It never blocks, so a slow watcher does not stall the shutters:
+// when the buffer of a watcher is full, its oldest event is dropped,
+// as the latest state matters most.
+func (sc *shutterSimulator) emit(shutter Shutter) {
+	sc.watchersMux.RLock()
+	defer sc.watchersMux.RUnlock()
+
+	e := ShutterEvent{Shutter: sc.faults.drift(shutter)}
+	for w := range sc.watchers {
+		select {
+		case w.events <- e:
+			continue
+		default:
+		}
+
+		select {
+		case <-w.events:
+		default:
+			// the watcher caught up in the meantime
+		}
+		select {
+		case w.events <- e:
+		default:
+			// another shutter took the free slot
+		}
+	}
+}
+
+func (sc *shutterSimulator) getShutter(name string) (*shutter, error) {
+	if s, ok := sc.data[name]; ok {
+		return s, nil
+	}
+	return nil, NotFoundError(fmt.Sprintf("shutter %q not found", name))
+}
+
+// addShutter adds a new shutter to the inventory, if it does not exist yet.
+func (sc *shutterSimulator) addShutter(name string) *shutter {
 	if s, ok := sc.data[name]; ok {
 		return s
 	}
-	sc.data[name] = newShutter(name)
-	return sc.data[name]
+	s := newShutter(name)
+	s.onChange = sc.emit
+	s.clock = sc.clock
+	s.faults = sc.faults
+	sc.data[name] = s
+	return s
 }
 
 // shuttersByName sorts Shutters by name
@@ -101,95 +261,231 @@ func (items shuttersByName) Less(i, j int) bool {
 type shutter struct {
 	name             string
 	stateMux         sync.RWMutex
-	closedPercentage int
+	position         float64 // precise closed percentage
 	targetPercentage int
 	moving           bool
+	motion           MotionProfile
+	// stuckTarget is the target the motor gave up on, if stuck is set.
+	stuckTarget int
+	stuck       bool
 
 	startOnce sync.Once
-	requests  chan int
-
-	incrementWait time.Duration
-	maxIncrement  int
+	// wakeup signals the worker that the target has changed.
+	// Pending signals are coalesced, as the worker always moves towards the latest target.
+	wakeup chan struct{}
+	stop   chan struct{}
+
+	// tick is the interval in which the position of a moving shutter is updated.
+	tick   time.Duration
+	clock  Clock
+	faults *FaultInjector
+
+	// onChange is called with the new state, whenever the shutter state changes.
+	onChange func(Shutter)
 }
 
 func newShutter(name string) *shutter {
 	return &shutter{
-		name:     name,
-		requests: make(chan int, 10),
+		name:   name,
+		wakeup: make(chan struct{}, 1),
+		stop:   make(chan struct{}),
 
 		// defaults
-		incrementWait: 1 * time.Second,
-		maxIncrement:  9,
+		motion: DefaultMotionProfile,
+		tick:   1 * time.Second,
+		clock:  RealClock,
 	}
 }
 
+// Set changes the target of the shutter.
+// A move already in progress is redirected towards the new target.
 func (s *shutter) Set(closedPercentage int) error {
 	if closedPercentage > 100 {
-		return ValidationError("cannot close more than 100%%")
+		return ValidationError("cannot close more than 100%")
 	}
 	if closedPercentage < 0 {
-		return ValidationError("cannot open more than 0%% closed")
+		return ValidationError("cannot open more than 0% closed")
 	}
 
+	s.stateMux.Lock()
+	s.targetPercentage = closedPercentage
+	s.stateMux.Unlock()
+
 	s.startOnce.Do(func() {
 		go s.worker()
 	})
-	s.requests <- closedPercentage
+	s.notifyWorker()
+	return nil
+}
+
+// SetMotionProfile changes how the shutter moves, effective with the next move.
+func (s *shutter) SetMotionProfile(profile MotionProfile) error {
+	if err := profile.Validate(); err != nil {
+		return err
+	}
+
+	s.stateMux.Lock()
+	defer s.stateMux.Unlock()
+	s.motion = profile
 	return nil
 }
 
+// Stop halts the shutter at its current position.
+func (s *shutter) Stop() {
+	s.stateMux.Lock()
+	s.targetPercentage = s.closedPercentage()
+	// snap to the full percent, otherwise the worker keeps moving to reach it
+	s.position = float64(s.targetPercentage)
+	s.stateMux.Unlock()
+
+	s.notifyWorker()
+}
+
+// notifyWorker wakes up the worker without blocking.
+func (s *shutter) notifyWorker() {
+	select {
+	case s.wakeup <- struct{}{}:
+	default:
+		// worker is already notified
+	}
+}
+
 func (s *shutter) Shutter() Shutter {
 	s.stateMux.RLock()
 	defer s.stateMux.RUnlock()
 	return Shutter{
 		Name:    s.name,
-		Current: s.closedPercentage,
+		Current: s.closedPercentage(),
 		Target:  s.targetPercentage,
 		Moving:  s.moving,
 	}
 }
 
+// closedPercentage returns the position rounded to full percent.
+// Must be called with stateMux held.
+func (s *shutter) closedPercentage() int {
+	return int(math.Round(s.position))
+}
+
+// changed notifies about a state change of the shutter.
+func (s *shutter) changed() {
+	if s.onChange != nil {
+		s.onChange(s.Shutter())
+	}
+}
+
 func (s *shutter) close() {
-	close(s.requests)
+	close(s.stop)
 }
 
 func (s *shutter) worker() {
-	for percentage := range s.requests {
-		s.stateMux.Lock()
-		s.moving = true
-		s.targetPercentage = percentage
-		s.stateMux.Unlock()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-s.wakeup:
+		}
 
-		for s.closedPercentage != s.targetPercentage {
-			time.Sleep(s.incrementWait)
+		if s.inPosition() || s.isStuck() {
+			continue
+		}
+		s.setMoving(true)
+		if !s.move() {
+			return
+		}
+		s.setMoving(false)
+	}
+}
 
-			// moving more than 10% per second would destroy the shutter ... and the window
-			diff := capDiff(s.closedPercentage-percentage, s.maxIncrement)
+// move runs the motor until the shutter reaches its latest target or gets stuck.
+// It returns false, if the shutter was closed while moving.
+func (s *shutter) move() bool {
+	ticker := s.clock.NewTicker(s.tick)
+	defer ticker.Stop()
+
+	s.stateMux.Lock()
+	var (
+		start   = s.clock.Now()
+		closing = float64(s.targetPercentage) > s.position
+		// a profile changed while moving only applies to the next move,
+		// switching profiles mid ramp would jump or even reverse the shutter
+		motion    = s.motion
+		travelled float64
+	)
+	s.stuck = false
+	s.stateMux.Unlock()
+
+	for !s.inPosition() {
+		select {
+		case <-s.stop:
+			return false
+		case <-s.wakeup:
+			// the target changed, so we re-evaluate right away
+		case <-ticker.C():
+		}
 
-			s.stateMux.Lock()
-			s.closedPercentage -= diff
+		s.stateMux.Lock()
+		if closing != (float64(s.targetPercentage) > s.position) {
+			// changing direction requires the motor to start again
+			start, closing, travelled = s.clock.Now(), !closing, 0
 			s.stateMux.Unlock()
+			continue
 		}
 
-		s.stateMux.Lock()
-		s.moving = false
-		s.targetPercentage = percentage
+		distance := motion.distance(s.clock.Now().Sub(start), closing)
+		step := distance - travelled
+		travelled = distance
+
+		previous, next := s.closedPercentage(), s.position
+		if closing {
+			next = math.Min(s.position+step, float64(s.targetPercentage))
+		} else {
+			next = math.Max(s.position-step, float64(s.targetPercentage))
+		}
+
+		at, stuck := s.faults.stuckAt(s.name)
+		stuck = stuck && at != s.targetPercentage &&
+			float64(at) >= math.Min(s.position, next) && float64(at) <= math.Max(s.position, next)
+		if stuck {
+			// the motor gives up before reaching the target
+			next = float64(at)
+			s.stuck, s.stuckTarget = true, s.targetPercentage
+		}
+		s.position = next
+		updated := s.closedPercentage() != previous
 		s.stateMux.Unlock()
-	}
-}
 
-func capDiff(diff, cap int) int {
-	if diff > 0 {
-		// limit positive move to cap
-		if diff > cap {
-			return cap
+		if updated {
+			s.changed()
+		}
+		if stuck {
+			return true
 		}
-		return diff
 	}
+	return true
+}
 
-	if diff < -cap {
-		// limit negative move to cap
-		return -cap
+// isStuck reports whether the motor already gave up on the current target
+// and the shutter is still stuck, so trying again would only fail again.
+func (s *shutter) isStuck() bool {
+	s.stateMux.RLock()
+	defer s.stateMux.RUnlock()
+	if !s.stuck || s.stuckTarget != s.targetPercentage {
+		return false
 	}
-	return diff
+	at, stuck := s.faults.stuckAt(s.name)
+	return stuck && float64(at) == s.position
+}
+
+func (s *shutter) inPosition() bool {
+	s.stateMux.RLock()
+	defer s.stateMux.RUnlock()
+	return s.position == float64(s.targetPercentage)
+}
+
+func (s *shutter) setMoving(moving bool) {
+	s.stateMux.Lock()
+	s.moving = moving
+	s.stateMux.Unlock()
+	s.changed()
 }
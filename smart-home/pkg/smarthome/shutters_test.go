@@ -1,57 +1,358 @@
 package smarthome
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 )
 
+// testMotionProfile moves shutters 10% per second.
+var testMotionProfile = MotionProfile{
+	CloseTime: 10 * time.Second,
+	OpenTime:  10 * time.Second,
+}
+
+// newTestShutter creates a shutter driven by a FakeClock,
+// reporting every state change on the returned channel.
+func newTestShutter(start int) (*shutter, *FakeClock, <-chan Shutter) {
+	events := make(chan Shutter, 100)
+	clock := NewFakeClock(time.Unix(0, 0))
+
+	s := newShutter("test")
+	s.position = float64(start)
+	s.targetPercentage = start
+	s.motion = testMotionProfile
+	s.clock = clock
+	s.onChange = func(state Shutter) {
+		events <- state
+	}
+	return s, clock, events
+}
+
+func expectState(t *testing.T, events <-chan Shutter, expected Shutter) {
+	t.Helper()
+
+	timer := time.NewTimer(time.Second)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		t.Fatalf("timeout waiting for shutter state %+v", expected)
+	case state := <-events:
+		if state != expected {
+			t.Fatalf("expected shutter state %+v, got %+v", expected, state)
+		}
+	}
+}
+
 func TestShutter(t *testing.T) {
 	tests := []struct {
 		Name             string
 		Start, SetClosed int
+		Positions        []int
 	}{
 		{
 			Name:  "from 0% to 43%",
 			Start: 0, SetClosed: 43,
+			Positions: []int{10, 20, 30, 40, 43},
 		},
 		{
 			Name:  "from 93% to 21%",
 			Start: 93, SetClosed: 21,
+			Positions: []int{83, 73, 63, 53, 43, 33, 23, 21},
 		},
 		{
 			Name:  "from 21% to 0%",
 			Start: 21, SetClosed: 0,
+			Positions: []int{11, 1, 0},
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.Name, func(t *testing.T) {
-			s := newShutter("test")
-			s.closedPercentage = test.Start
-			s.incrementWait = 0
+			s, clock, events := newTestShutter(test.Start)
 			defer s.close()
 
 			err := s.Set(test.SetClosed)
 			if err != nil {
 				t.Error("unexpected error calling .SetClosed")
 			}
+			expectState(t, events, Shutter{Name: "test", Current: test.Start, Target: test.SetClosed, Moving: true})
 
-			timer := time.NewTimer(500 * time.Millisecond)
-			defer timer.Stop()
-
-			var p int
-			for {
-				select {
-				case <-timer.C:
-					t.Errorf("timeout waiting for shutter to close to %d%%, is: %d", test.SetClosed, p)
-					return
-				default:
-					p = s.Shutter().Current
-					if p == test.SetClosed {
-						return
-					}
-				}
+			for _, p := range test.Positions {
+				clock.BlockUntil(1)
+				clock.Step(time.Second)
+				expectState(t, events, Shutter{Name: "test", Current: p, Target: test.SetClosed, Moving: true})
 			}
+			expectState(t, events, Shutter{Name: "test", Current: test.SetClosed, Target: test.SetClosed})
 		})
 	}
 }
+
+func TestShutterRedirect(t *testing.T) {
+	s, clock, events := newTestShutter(0)
+	defer s.close()
+
+	if err := s.Set(100); err != nil {
+		t.Fatalf("unexpected error calling .Set: %v", err)
+	}
+	expectState(t, events, Shutter{Name: "test", Current: 0, Target: 100, Moving: true})
+
+	clock.BlockUntil(1)
+	clock.Step(time.Second)
+	expectState(t, events, Shutter{Name: "test", Current: 10, Target: 100, Moving: true})
+
+	// a newer target replaces the previous one, while moving
+	if err := s.Set(15); err != nil {
+		t.Fatalf("unexpected error calling .Set: %v", err)
+	}
+	clock.Step(time.Second)
+	expectState(t, events, Shutter{Name: "test", Current: 15, Target: 15, Moving: true})
+	expectState(t, events, Shutter{Name: "test", Current: 15, Target: 15})
+}
+
+func TestShutterMotionProfileWhileMoving(t *testing.T) {
+	s, clock, events := newTestShutter(0)
+	defer s.close()
+
+	if err := s.Set(50); err != nil {
+		t.Fatalf("unexpected error calling .Set: %v", err)
+	}
+	expectState(t, events, Shutter{Name: "test", Current: 0, Target: 50, Moving: true})
+	for _, p := range []int{10, 20, 30} {
+		clock.BlockUntil(1)
+		clock.Step(time.Second)
+		expectState(t, events, Shutter{Name: "test", Current: p, Target: 50, Moving: true})
+	}
+
+	// 10 times slower, the current move keeps its profile
+	if err := s.SetMotionProfile(MotionProfile{CloseTime: 100 * time.Second, OpenTime: 100 * time.Second}); err != nil {
+		t.Fatalf("unexpected error calling .SetMotionProfile: %v", err)
+	}
+	for _, p := range []int{40, 50} {
+		clock.Step(time.Second)
+		expectState(t, events, Shutter{Name: "test", Current: p, Target: 50, Moving: true})
+	}
+	expectState(t, events, Shutter{Name: "test", Current: 50, Target: 50})
+
+	// the next move uses the new profile
+	if err := s.Set(60); err != nil {
+		t.Fatalf("unexpected error calling .Set: %v", err)
+	}
+	expectState(t, events, Shutter{Name: "test", Current: 50, Target: 60, Moving: true})
+	clock.BlockUntil(1)
+	clock.Step(time.Second)
+	expectState(t, events, Shutter{Name: "test", Current: 51, Target: 60, Moving: true})
+}
+
+func TestShutterStop(t *testing.T) {
+	s, clock, events := newTestShutter(0)
+	defer s.close()
+
+	if err := s.Set(100); err != nil {
+		t.Fatalf("unexpected error calling .Set: %v", err)
+	}
+	expectState(t, events, Shutter{Name: "test", Current: 0, Target: 100, Moving: true})
+
+	clock.BlockUntil(1)
+	clock.Step(time.Second)
+	expectState(t, events, Shutter{Name: "test", Current: 10, Target: 100, Moving: true})
+
+	// stopping takes effect right away, without waiting for the next tick
+	s.Stop()
+	expectState(t, events, Shutter{Name: "test", Current: 10, Target: 10})
+}
+
+func TestShutterStopBetweenPercents(t *testing.T) {
+	s, clock, events := newTestShutter(0)
+	defer s.close()
+	// 11.1% per second, so the shutter stops between full percents
+	s.motion = MotionProfile{CloseTime: 9 * time.Second, OpenTime: 9 * time.Second}
+
+	if err := s.Set(100); err != nil {
+		t.Fatalf("unexpected error calling .Set: %v", err)
+	}
+	expectState(t, events, Shutter{Name: "test", Current: 0, Target: 100, Moving: true})
+
+	clock.BlockUntil(1)
+	clock.Step(time.Second)
+	expectState(t, events, Shutter{Name: "test", Current: 11, Target: 100, Moving: true})
+
+	s.Stop()
+	expectState(t, events, Shutter{Name: "test", Current: 11, Target: 11})
+
+	// the motor is off and stays off
+	clock.BlockUntil(0)
+	for i := 0; i < 3; i++ {
+		clock.Step(time.Second)
+	}
+	select {
+	case state := <-events:
+		t.Fatalf("unexpected shutter state after stop %+v", state)
+	case <-time.After(100 * time.Millisecond):
+	}
+	s.stateMux.RLock()
+	defer s.stateMux.RUnlock()
+	if s.position != 11 {
+		t.Errorf("expected position 11, got %v", s.position)
+	}
+}
+
+func TestShutterSimulatorWatch(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	sc := newShutterSimulator(clock, nil)
+	defer sc.close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := sc.Watch(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error calling .Watch: %v", err)
+	}
+
+	if err := sc.Register(ctx, "test"); err != nil {
+		t.Fatalf("unexpected error calling .Register: %v", err)
+	}
+	if err := sc.SetMotionProfile(ctx, "test", testMotionProfile); err != nil {
+		t.Fatalf("unexpected error calling .SetMotionProfile: %v", err)
+	}
+	if err := sc.Set(ctx, "test", 20); err != nil {
+		t.Fatalf("unexpected error calling .Set: %v", err)
+	}
+
+	expected := []Shutter{
+		{Name: "test", Current: 0, Target: 20, Moving: true},
+		{Name: "test", Current: 10, Target: 20, Moving: true},
+		{Name: "test", Current: 20, Target: 20, Moving: true},
+		{Name: "test", Current: 20, Target: 20},
+	}
+	for i, e := range expected {
+		if i > 0 && e.Moving {
+			clock.BlockUntil(1)
+			clock.Step(time.Second)
+		}
+
+		event := <-events
+		if event.Shutter != e {
+			t.Fatalf("expected event %d to be %+v, got %+v", i, e, event.Shutter)
+		}
+	}
+}
+
+func TestShutterSimulatorSlowWatcher(t *testing.T) {
+	sc := newShutterSimulator(NewFakeClock(time.Unix(0, 0)), nil)
+	defer sc.close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := sc.Watch(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error calling .Watch: %v", err)
+	}
+
+	// nobody reads the events, emitting must not block
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i <= 200; i++ {
+			sc.emit(Shutter{Name: "test", Current: i % 101})
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("emitting events blocked on a slow watcher")
+	}
+
+	var last Shutter
+	for len(events) > 0 {
+		last = (<-events).Shutter
+	}
+	if last != (Shutter{Name: "test", Current: 99}) {
+		t.Errorf("expected the latest state to be kept, got %+v", last)
+	}
+}
+
+func TestShutterSimulatorRestore(t *testing.T) {
+	sc := newShutterSimulator(NewFakeClock(time.Unix(0, 0)), nil)
+	defer sc.close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := sc.Watch(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error calling .Watch: %v", err)
+	}
+
+	for _, name := range []string{"idle", "moving"} {
+		if err := sc.Register(ctx, name); err != nil {
+			t.Fatalf("unexpected error calling .Register: %v", err)
+		}
+	}
+	err = sc.restore([]Shutter{
+		{Name: "idle", Current: 30, Target: 0},
+		{Name: "moving", Current: 10, Target: 50, Moving: true},
+		{Name: "removed", Current: 20, Target: 20},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error calling .restore: %v", err)
+	}
+
+	if _, err := sc.Get(ctx, "removed"); err == nil {
+		t.Error("expected shutter removed from the inventory not to be restored")
+	}
+
+	idle, _ := sc.Get(ctx, "idle")
+	if idle.Moving || idle.Current != 30 || idle.Target != 30 {
+		t.Errorf("expected idle shutter to stay at 30%%, is: %+v", idle)
+	}
+
+	moving := (<-events).Shutter
+	if moving != (Shutter{Name: "moving", Current: 10, Target: 50, Moving: true}) {
+		t.Errorf("expected shutter to resume moving to 50%%, is: %+v", moving)
+	}
+}
+
+func TestShutterSimulatorNotFound(t *testing.T) {
+	sc := newShutterSimulator(NewFakeClock(time.Unix(0, 0)), nil)
+	defer sc.close()
+	ctx := context.Background()
+
+	if _, err := sc.Get(ctx, "typo"); err == nil {
+		t.Error("expected error getting unknown shutter")
+	} else if _, ok := err.(NotFoundError); !ok {
+		t.Errorf("expected NotFoundError, got: %v", err)
+	}
+	if err := sc.Set(ctx, "typo", 10); err == nil {
+		t.Error("expected error setting unknown shutter")
+	}
+
+	shutters, _ := sc.List(ctx)
+	if len(shutters) != 0 {
+		t.Errorf("expected no phantom shutters to be discovered, got: %v", shutters)
+	}
+}
+
+func TestShutterSimulatorValidation(t *testing.T) {
+	sc := newShutterSimulator(NewFakeClock(time.Unix(0, 0)), nil)
+	defer sc.close()
+	ctx := context.Background()
+	if err := sc.Register(ctx, "living-room"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, closedPercentage := range []int{-1, 101} {
+		err := sc.Set(ctx, "living-room", closedPercentage)
+		var validationErr ValidationError
+		if !errors.As(fmt.Errorf("wrapped: %w", err), &validationErr) {
+			t.Errorf("expected ValidationError setting %d%%, got: %v", closedPercentage, err)
+		}
+	}
+	err := sc.SetMotionProfile(ctx, "living-room", MotionProfile{})
+	var validationErr ValidationError
+	if !errors.As(fmt.Errorf("wrapped: %w", err), &validationErr) {
+		t.Errorf("expected ValidationError setting an empty motion profile, got: %v", err)
+	}
+}
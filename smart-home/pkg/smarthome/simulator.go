@@ -0,0 +1,158 @@
+package smarthome
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// SimulatorBackend is the name of the in-memory device simulator backend.
+const SimulatorBackend = "simulator"
+
+const (
+	shuttersSnapshotKey = "shutters"
+	lightsSnapshotKey   = "lights"
+	sensorsSnapshotKey  = "sensors"
+)
+
+func init() {
+	RegisterBackend(SimulatorBackend, newSimulator)
+}
+
+// simulator is a Backend simulating shutters, lights and sensors in memory.
+type simulator struct {
+	shutters *shutterSimulator
+	lights   *lightSimulator
+	sensors  *sensorSimulator
+
+	opts BackendOptions
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newSimulator(opts BackendOptions) (Backend, error) {
+	if opts.Clock == nil {
+		opts.Clock = RealClock
+	}
+
+	s := &simulator{
+		shutters: newShutterSimulator(opts.Clock, opts.Faults),
+		lights:   newLightSimulator(opts.Faults),
+		sensors:  newSensorSimulator(opts.Faults),
+
+		opts: opts,
+		stop: make(chan struct{}),
+	}
+	ctx := context.Background()
+	for _, name := range opts.Inventory.Shutters {
+		if err := s.shutters.Register(ctx, name); err != nil {
+			return nil, fmt.Errorf("registering shutter %q: %w", name, err)
+		}
+	}
+	for _, name := range opts.Inventory.Lights {
+		if err := s.lights.Register(ctx, name); err != nil {
+			return nil, fmt.Errorf("registering light %q: %w", name, err)
+		}
+	}
+	for _, name := range opts.Inventory.Sensors {
+		if err := s.sensors.Register(ctx, name); err != nil {
+			return nil, fmt.Errorf("registering sensor %q: %w", name, err)
+		}
+	}
+	// after registering, so devices removed from the inventory are not restored
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	if s.opts.Store != nil && s.opts.SnapshotInterval > 0 {
+		s.wg.Add(1)
+		go s.snapshotLoop()
+	}
+	return s, nil
+}
+
+func (s *simulator) Shutters() ShutterClient {
+	return s.shutters
+}
+
+func (s *simulator) Lights() LightClient {
+	return s.lights
+}
+
+func (s *simulator) Sensors() SensorClient {
+	return s.sensors
+}
+
+func (s *simulator) Close() error {
+	close(s.stop)
+	s.wg.Wait()
+
+	err := s.save()
+	s.shutters.close()
+	return err
+}
+
+// load restores the state of the registered devices from the Store.
+func (s *simulator) load() error {
+	if s.opts.Store == nil {
+		return nil
+	}
+
+	var shutters []Shutter
+	if err := s.opts.Store.Load(shuttersSnapshotKey, &shutters); err != nil {
+		return fmt.Errorf("loading shutters: %w", err)
+	}
+	if err := s.shutters.restore(shutters); err != nil {
+		return fmt.Errorf("restoring shutters: %w", err)
+	}
+
+	var lights []Light
+	if err := s.opts.Store.Load(lightsSnapshotKey, &lights); err != nil {
+		return fmt.Errorf("loading lights: %w", err)
+	}
+	s.lights.restore(lights)
+
+	var sensors []Sensor
+	if err := s.opts.Store.Load(sensorsSnapshotKey, &sensors); err != nil {
+		return fmt.Errorf("loading sensors: %w", err)
+	}
+	s.sensors.restore(sensors)
+	return nil
+}
+
+// save writes the device state to the Store.
+func (s *simulator) save() error {
+	if s.opts.Store == nil {
+		return nil
+	}
+
+	if err := s.opts.Store.Save(shuttersSnapshotKey, s.shutters.snapshot()); err != nil {
+		return fmt.Errorf("saving shutters: %w", err)
+	}
+	if err := s.opts.Store.Save(lightsSnapshotKey, s.lights.snapshot()); err != nil {
+		return fmt.Errorf("saving lights: %w", err)
+	}
+	if err := s.opts.Store.Save(sensorsSnapshotKey, s.sensors.snapshot()); err != nil {
+		return fmt.Errorf("saving sensors: %w", err)
+	}
+	return nil
+}
+
+// snapshotLoop periodically saves the device state, until the simulator is closed.
+func (s *simulator) snapshotLoop() {
+	defer s.wg.Done()
+
+	ticker := s.opts.Clock.NewTicker(s.opts.SnapshotInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C():
+			if err := s.save(); err != nil && s.opts.ErrorHandler != nil {
+				s.opts.ErrorHandler(err)
+			}
+		}
+	}
+}
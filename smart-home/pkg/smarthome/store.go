@@ -0,0 +1,84 @@
+package smarthome
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Store persists snapshots of device state.
+type Store interface {
+	// Load decodes the snapshot saved under key into v.
+	// v is left untouched, if no snapshot exists.
+	Load(key string, v interface{}) error
+	// Save replaces the snapshot under key with v.
+	Save(key string, v interface{}) error
+}
+
+// FileStore is a Store saving snapshots as JSON files into a directory.
+type FileStore struct {
+	dir string
+}
+
+var _ Store = (*FileStore)(nil)
+
+// NewFileStore creates a FileStore, creating the given directory if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("creating state directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+func (s *FileStore) Load(key string, v interface{}) error {
+	js, err := ioutil.ReadFile(s.path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading snapshot %q: %w", key, err)
+	}
+
+	if err := json.Unmarshal(js, v); err != nil {
+		return fmt.Errorf("decoding snapshot %q: %w", key, err)
+	}
+	return nil
+}
+
+// Save writes the snapshot into a temporary file first and renames it afterwards,
+// so a crash never leaves a partially written snapshot behind.
+func (s *FileStore) Save(key string, v interface{}) error {
+	js, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encoding snapshot %q: %w", key, err)
+	}
+
+	f, err := ioutil.TempFile(s.dir, key+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("creating snapshot %q: %w", key, err)
+	}
+	defer os.Remove(f.Name()) // no-op after a successful rename
+
+	if _, err := f.Write(js); err != nil {
+		f.Close()
+		return fmt.Errorf("writing snapshot %q: %w", key, err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("syncing snapshot %q: %w", key, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("closing snapshot %q: %w", key, err)
+	}
+
+	if err := os.Rename(f.Name(), s.path(key)); err != nil {
+		return fmt.Errorf("replacing snapshot %q: %w", key, err)
+	}
+	return nil
+}
+
+func (s *FileStore) path(key string) string {
+	return filepath.Join(s.dir, key+".json")
+}
@@ -0,0 +1,51 @@
+package smarthome
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smarthome")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := NewFileStore(filepath.Join(dir, "state"))
+	if err != nil {
+		t.Fatalf("unexpected error calling NewFileStore: %v", err)
+	}
+
+	var missing []Light
+	if err := store.Load("lights", &missing); err != nil {
+		t.Errorf("unexpected error loading missing snapshot: %v", err)
+	}
+	if missing != nil {
+		t.Errorf("expected missing snapshot to leave value untouched, got: %v", missing)
+	}
+
+	lights := []Light{{Name: "hall", On: true}, {Name: "kitchen"}}
+	if err := store.Save("lights", lights); err != nil {
+		t.Fatalf("unexpected error calling .Save: %v", err)
+	}
+
+	var loaded []Light
+	if err := store.Load("lights", &loaded); err != nil {
+		t.Fatalf("unexpected error calling .Load: %v", err)
+	}
+	if !reflect.DeepEqual(lights, loaded) {
+		t.Errorf("expected %v, got %v", lights, loaded)
+	}
+
+	files, err := ioutil.ReadDir(filepath.Join(dir, "state"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("expected only the snapshot file to remain, got %d files", len(files))
+	}
+}
@@ -0,0 +1,106 @@
+// Package sun computes sunrise and sunset times locally, without network access.
+package sun
+
+import (
+	"math"
+	"time"
+)
+
+// Event is a daily event of the sun.
+type Event string
+
+const (
+	Sunrise Event = "Sunrise"
+	Sunset  Event = "Sunset"
+)
+
+const (
+	// j2000 is the Julian date of 2000-01-01 12:00 UTC.
+	j2000 = 2451545.0
+	// unixEpoch is the Julian date of 1970-01-01 00:00 UTC.
+	unixEpoch = 2440587.5
+	// horizon is the altitude of the sun's center at sunrise and sunset in degrees,
+	// accounting for atmospheric refraction and the radius of the sun.
+	horizon = -0.833
+)
+
+// Times returns the sunrise and sunset on the given day at the given location.
+// Latitude is positive north of the equator, longitude is positive east of Greenwich.
+// The day is the calendar date of date in its location.
+// ok is false, when the sun does not rise or set on that day, e.g. during polar night.
+// Times are accurate to about a minute.
+func Times(date time.Time, latitude, longitude float64) (sunrise, sunset time.Time, ok bool) {
+	// days since J2000 of the calendar day
+	year, month, day := date.Date()
+	n := math.Round(julianDate(time.Date(year, month, day, 12, 0, 0, 0, time.UTC)) - j2000)
+
+	// mean solar time of the local noon
+	meanSolarNoon := n - longitude/360
+	meanAnomaly := math.Mod(357.5291+0.98560028*meanSolarNoon, 360)
+	m := radians(meanAnomaly)
+	center := 1.9148*math.Sin(m) + 0.0200*math.Sin(2*m) + 0.0003*math.Sin(3*m)
+	eclipticLongitude := radians(math.Mod(meanAnomaly+center+180+102.9372, 360))
+	transit := j2000 + meanSolarNoon + 0.0053*math.Sin(m) - 0.0069*math.Sin(2*eclipticLongitude)
+
+	declination := math.Asin(math.Sin(eclipticLongitude) * math.Sin(radians(23.4397)))
+	phi := radians(latitude)
+	cosHourAngle := (math.Sin(radians(horizon)) - math.Sin(phi)*math.Sin(declination)) /
+		(math.Cos(phi) * math.Cos(declination))
+	if cosHourAngle < -1 || cosHourAngle > 1 {
+		// midnight sun or polar night
+		return time.Time{}, time.Time{}, false
+	}
+	hourAngle := degrees(math.Acos(cosHourAngle))
+
+	sunrise = fromJulianDate(transit - hourAngle/360).In(date.Location())
+	sunset = fromJulianDate(transit + hourAngle/360).In(date.Location())
+	return sunrise, sunset, true
+}
+
+// Schedule triggers daily at a sun Event, shifted by an Offset.
+// It implements the Next method of cron.Schedule.
+type Schedule struct {
+	Event     Event
+	Offset    time.Duration
+	Latitude  float64
+	Longitude float64
+}
+
+// Next returns the next time the Schedule triggers after the given time,
+// or the zero time, if the Event does not happen within the next year.
+func (s Schedule) Next(after time.Time) time.Time {
+	// start a day early, as the offset or the time zone may move the trigger to the day before
+	day := after.UTC().AddDate(0, 0, -1)
+	for i := 0; i < 368; i++ {
+		sunrise, sunset, ok := Times(day.AddDate(0, 0, i), s.Latitude, s.Longitude)
+		if !ok {
+			continue
+		}
+
+		t := sunrise
+		if s.Event == Sunset {
+			t = sunset
+		}
+		if t = t.Add(s.Offset); t.After(after) {
+			return t.In(after.Location())
+		}
+	}
+	return time.Time{}
+}
+
+func julianDate(t time.Time) float64 {
+	return float64(t.Unix())/86400 + unixEpoch
+}
+
+func fromJulianDate(jd float64) time.Time {
+	seconds := (jd - unixEpoch) * 86400
+	return time.Unix(0, int64(seconds*float64(time.Second))).UTC()
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func degrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
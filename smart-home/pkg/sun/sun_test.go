@@ -0,0 +1,160 @@
+package sun
+
+import (
+	"testing"
+	"time"
+)
+
+// tolerance is the accepted deviation from published sun times, which are rounded to the minute.
+const tolerance = 2 * time.Minute
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone database not available: %v", err)
+	}
+	return location
+}
+
+func TestTimes(t *testing.T) {
+	tests := []struct {
+		name                string
+		latitude, longitude float64
+		timeZone            string
+		date                string
+		sunrise, sunset     string
+	}{
+		{
+			name:     "Berlin summer solstice",
+			latitude: 52.52, longitude: 13.405,
+			timeZone: "Europe/Berlin",
+			date:     "2020-06-21",
+			sunrise:  "04:43", sunset: "21:33",
+		},
+		{
+			name:     "Berlin winter solstice",
+			latitude: 52.52, longitude: 13.405,
+			timeZone: "Europe/Berlin",
+			date:     "2020-12-21",
+			sunrise:  "08:15", sunset: "15:54",
+		},
+		{
+			name:     "New York west of Greenwich",
+			latitude: 40.7128, longitude: -74.006,
+			timeZone: "America/New_York",
+			date:     "2020-12-21",
+			sunrise:  "07:16", sunset: "16:32",
+		},
+		{
+			name:     "Sydney south of the equator",
+			latitude: -33.8688, longitude: 151.2093,
+			timeZone: "Australia/Sydney",
+			date:     "2020-12-21",
+			sunrise:  "05:41", sunset: "20:05",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			location := mustLoadLocation(t, test.timeZone)
+			date, err := time.ParseInLocation("2006-01-02", test.date, location)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sunrise, sunset, ok := Times(date, test.latitude, test.longitude)
+			if !ok {
+				t.Fatal("expected the sun to rise and set")
+			}
+			expectTime(t, "sunrise", sunrise, test.date+" "+test.sunrise, location)
+			expectTime(t, "sunset", sunset, test.date+" "+test.sunset, location)
+		})
+	}
+}
+
+func TestTimesPolar(t *testing.T) {
+	// Tromsø, Norway
+	for _, date := range []string{"2020-06-21", "2020-12-21"} {
+		d, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sunrise, sunset, ok := Times(d, 69.6492, 18.9553); ok {
+			t.Errorf("expected no sunrise and sunset on %s, got %s and %s", date, sunrise, sunset)
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	location := mustLoadLocation(t, "Europe/Berlin")
+	berlin := Schedule{Latitude: 52.52, Longitude: 13.405}
+
+	tests := []struct {
+		name     string
+		event    Event
+		offset   time.Duration
+		after    string
+		expected string
+	}{
+		{
+			name:     "sunrise later today",
+			event:    Sunrise,
+			after:    "2020-06-21 00:00",
+			expected: "2020-06-21 04:43",
+		},
+		{
+			name:     "sunrise tomorrow",
+			event:    Sunrise,
+			after:    "2020-06-21 12:00",
+			expected: "2020-06-22 04:43",
+		},
+		{
+			name:     "sunset with offset",
+			event:    Sunset,
+			offset:   -30 * time.Minute,
+			after:    "2020-12-21 12:00",
+			expected: "2020-12-21 15:24",
+		},
+		{
+			name:     "offset into the next day",
+			event:    Sunset,
+			offset:   3 * time.Hour,
+			after:    "2020-06-21 23:00",
+			expected: "2020-06-22 00:33",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			after, err := time.ParseInLocation("2006-01-02 15:04", test.after, location)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			s := berlin
+			s.Event, s.Offset = test.event, test.offset
+			expectTime(t, "next", s.Next(after), test.expected, location)
+		})
+	}
+}
+
+func TestScheduleNextPolar(t *testing.T) {
+	// the sun returns to Tromsø, Norway mid January after the polar night
+	after := time.Date(2020, time.December, 1, 0, 0, 0, 0, time.UTC)
+	next := Schedule{Event: Sunrise, Latitude: 69.6492, Longitude: 18.9553}.Next(after)
+	if next.Year() != 2021 || next.Month() != time.January || next.Day() < 10 || next.Day() > 20 {
+		t.Errorf("expected next sunrise mid January 2021, got %s", next)
+	}
+}
+
+func expectTime(t *testing.T, name string, actual time.Time, expected string, location *time.Location) {
+	t.Helper()
+	e, err := time.ParseInLocation("2006-01-02 15:04", expected, location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := actual.Sub(e); d < -tolerance || d > tolerance {
+		t.Errorf("expected %s at %s, got %s", name, e, actual.In(location))
+	}
+}
@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	smarthomev1alpha1 "github.com/loodse/godays-2020-k8s-workshop/smart-home/api/v1alpha1"
+	"github.com/loodse/godays-2020-k8s-workshop/smart-home/pkg/smarthome"
+)
+
+// shutterStep is the change in closed percentage per key press.
+const shutterStep = 10
+
+// deviceControl changes devices by patching their custom resources,
+// so the cluster stays the source of truth and the reconcilers
+// are the only ones talking to the smart home backend.
+type deviceControl struct {
+	client client.Client
+	// shutters is only read from, to stop shutters where they are.
+	shutters smarthome.ShutterClient
+}
+
+// moveShutterBy moves the shutter by delta percent, relative to its current target.
+func (c *deviceControl) moveShutterBy(ctx context.Context, device smarthome.Shutter, delta int) error {
+	return c.patchShutter(ctx, device.Name, func(shutter *smarthomev1alpha1.Shutter) {
+		target := shutter.Spec.ClosedPercentage
+		if shutter.Spec.Position != "" {
+			// the spec percentage is ignored, so we continue from the resolved position
+			target = shutter.Status.TargetPercentage
+		}
+		shutter.Spec.Position = ""
+		shutter.Spec.ClosedPercentage = clamp(target+delta, 0, 100)
+	})
+}
+
+// moveShutterTo moves the shutter to the given closed percentage.
+func (c *deviceControl) moveShutterTo(ctx context.Context, device smarthome.Shutter, closedPercentage int) error {
+	return c.patchShutter(ctx, device.Name, func(shutter *smarthomev1alpha1.Shutter) {
+		shutter.Spec.Position = ""
+		shutter.Spec.ClosedPercentage = closedPercentage
+	})
+}
+
+// stopShutter stops the shutter, by making its current position the new target.
+// The position is read again, as the rendered state may be outdated by a redraw interval.
+func (c *deviceControl) stopShutter(ctx context.Context, device smarthome.Shutter) error {
+	state, err := c.shutters.Get(ctx, device.Name)
+	if err != nil {
+		return fmt.Errorf("getting position of shutter %q: %w", device.Name, err)
+	}
+	return c.moveShutterTo(ctx, device, state.Current)
+}
+
+func (c *deviceControl) patchShutter(
+	ctx context.Context, deviceName string, mutate func(*smarthomev1alpha1.Shutter),
+) error {
+	key, err := objectKey(deviceName)
+	if err != nil {
+		return err
+	}
+	shutter := &smarthomev1alpha1.Shutter{}
+	if err := c.client.Get(ctx, key, shutter); err != nil {
+		return fmt.Errorf("getting shutter %q: %w", deviceName, err)
+	}
+	if shutter.Status.LockedBy != "" {
+		// the webhook rejects changes anyway
+		return fmt.Errorf("shutter %q is locked by safety interlock %q", deviceName, shutter.Status.LockedBy)
+	}
+
+	patch := client.MergeFrom(shutter.DeepCopy())
+	mutate(shutter)
+	if err := c.client.Patch(ctx, shutter, patch); err != nil {
+		return fmt.Errorf("patching shutter %q: %w", deviceName, err)
+	}
+	return nil
+}
+
+// toggleLight switches the light on, when it is off and vice versa.
+func (c *deviceControl) toggleLight(ctx context.Context, device smarthome.Light) error {
+	key, err := objectKey(device.Name)
+	if err != nil {
+		return err
+	}
+	light := &smarthomev1alpha1.Light{}
+	if err := c.client.Get(ctx, key, light); err != nil {
+		return fmt.Errorf("getting light %q: %w", device.Name, err)
+	}
+
+	patch := client.MergeFrom(light.DeepCopy())
+	light.Spec.On = !light.Spec.On
+	if err := c.client.Patch(ctx, light, patch); err != nil {
+		return fmt.Errorf("patching light %q: %w", device.Name, err)
+	}
+	return nil
+}
+
+// objectKey returns the key of the custom resource backing a device,
+// as devices are registered as namespace/name.
+func objectKey(deviceName string) (types.NamespacedName, error) {
+	parts := strings.SplitN(deviceName, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return types.NamespacedName{}, fmt.Errorf("device %q is not named namespace/name", deviceName)
+	}
+	return types.NamespacedName{Namespace: parts[0], Name: parts[1]}, nil
+}
+
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
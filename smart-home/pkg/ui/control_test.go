@@ -0,0 +1,173 @@
+package ui
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	smarthomev1alpha1 "github.com/loodse/godays-2020-k8s-workshop/smart-home/api/v1alpha1"
+	"github.com/loodse/godays-2020-k8s-workshop/smart-home/pkg/smarthome"
+)
+
+func TestDeviceControlShutter(t *testing.T) {
+	tests := []struct {
+		name          string
+		spec          smarthomev1alpha1.ShutterSpec
+		status        smarthomev1alpha1.ShutterStatus
+		action        func(ctx context.Context, c *deviceControl, device smarthome.Shutter) error
+		expectedPatch string
+		expectedErr   bool
+	}{
+		{
+			name: "close by 10%",
+			spec: smarthomev1alpha1.ShutterSpec{ClosedPercentage: 50},
+			action: func(ctx context.Context, c *deviceControl, device smarthome.Shutter) error {
+				return c.moveShutterBy(ctx, device, shutterStep)
+			},
+			expectedPatch: `{"spec":{"closedPercentage":60}}`,
+		},
+		{
+			name: "open by 10% stops at 0",
+			spec: smarthomev1alpha1.ShutterSpec{ClosedPercentage: 5},
+			action: func(ctx context.Context, c *deviceControl, device smarthome.Shutter) error {
+				return c.moveShutterBy(ctx, device, -shutterStep)
+			},
+			expectedPatch: `{"spec":{"closedPercentage":null}}`,
+		},
+		{
+			name:   "close by 10% from a named position",
+			spec:   smarthomev1alpha1.ShutterSpec{Position: "half"},
+			status: smarthomev1alpha1.ShutterStatus{TargetPercentage: 50},
+			action: func(ctx context.Context, c *deviceControl, device smarthome.Shutter) error {
+				return c.moveShutterBy(ctx, device, shutterStep)
+			},
+			expectedPatch: `{"spec":{"closedPercentage":60,"position":null}}`,
+		},
+		{
+			// the shutter moved on since the device was rendered at 30%
+			name: "stop",
+			spec: smarthomev1alpha1.ShutterSpec{ClosedPercentage: 100},
+			action: func(ctx context.Context, c *deviceControl, device smarthome.Shutter) error {
+				return c.stopShutter(ctx, device)
+			},
+			expectedPatch: `{"spec":{"closedPercentage":45}}`,
+		},
+		{
+			name:   "locked",
+			spec:   smarthomev1alpha1.ShutterSpec{ClosedPercentage: 50},
+			status: smarthomev1alpha1.ShutterStatus{LockedBy: "storm"},
+			action: func(ctx context.Context, c *deviceControl, device smarthome.Shutter) error {
+				return c.moveShutterBy(ctx, device, shutterStep)
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			shutter := &smarthomev1alpha1.Shutter{
+				ObjectMeta: metav1.ObjectMeta{Name: "living-room", Namespace: "default"},
+				Spec:       test.spec,
+				Status:     test.status,
+			}
+			recorder := &patchRecorder{Client: fake.NewFakeClientWithScheme(testScheme(t), shutter)}
+			c := &deviceControl{client: recorder, shutters: shutterPosition{current: 45}}
+			device := smarthome.Shutter{Name: "default/living-room", Target: 100, Current: 30, Moving: true}
+
+			err := test.action(ctx, c, device)
+			if test.expectedErr {
+				if err == nil || len(recorder.patches) != 0 {
+					t.Errorf("expected an error and no patch, got %v and %v", err, recorder.patches)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(recorder.patches) != 1 || recorder.patches[0] != test.expectedPatch {
+				t.Errorf("expected patch %s, got %v", test.expectedPatch, recorder.patches)
+			}
+		})
+	}
+}
+
+func TestDeviceControlToggleLight(t *testing.T) {
+	ctx := context.Background()
+	light := &smarthomev1alpha1.Light{
+		ObjectMeta: metav1.ObjectMeta{Name: "bedroom", Namespace: "default"},
+	}
+	c := &deviceControl{client: fake.NewFakeClientWithScheme(testScheme(t), light)}
+	device := smarthome.Light{Name: "default/bedroom"}
+
+	for _, expected := range []bool{true, false} {
+		if err := c.toggleLight(ctx, device); err != nil {
+			t.Fatal(err)
+		}
+		got := &smarthomev1alpha1.Light{}
+		key := types.NamespacedName{Namespace: "default", Name: "bedroom"}
+		if err := c.client.Get(ctx, key, got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Spec.On != expected {
+			t.Errorf("expected light on=%v, got %v", expected, got.Spec.On)
+		}
+	}
+}
+
+func TestObjectKey(t *testing.T) {
+	key, err := objectKey("default/living-room")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != (types.NamespacedName{Namespace: "default", Name: "living-room"}) {
+		t.Errorf("unexpected key %v", key)
+	}
+
+	for _, name := range []string{"living-room", "/living-room", "default/"} {
+		if _, err := objectKey(name); err == nil {
+			t.Errorf("expected an error for %q", name)
+		}
+	}
+}
+
+// shutterPosition reports all shutters moving at the given position.
+// Only Get is implemented.
+type shutterPosition struct {
+	smarthome.ShutterClient
+	current int
+}
+
+func (p shutterPosition) Get(ctx context.Context, name string) (smarthome.Shutter, error) {
+	return smarthome.Shutter{Name: name, Target: 100, Current: p.current, Moving: true}, nil
+}
+
+// patchRecorder records the patches sent to the API server.
+// The fake client can not remove fields with merge patches,
+// so we check the patches themselves.
+type patchRecorder struct {
+	client.Client
+	patches []string
+}
+
+func (r *patchRecorder) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	data, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+	r.patches = append(r.patches, string(data))
+	return r.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func testScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := smarthomev1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return scheme
+}
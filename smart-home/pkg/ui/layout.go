@@ -0,0 +1,136 @@
+package ui
+
+import (
+	"fmt"
+	"image"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+
+	"github.com/loodse/godays-2020-k8s-workshop/smart-home/pkg/smarthome"
+)
+
+// deviceHeight is the number of rows a single device takes up in a devicePanel.
+const deviceHeight = 3
+
+// devicePanel stacks device widgets in a bordered block.
+// If not all devices fit, the panel scrolls to keep the selected device visible.
+type devicePanel struct {
+	ui.Block
+	title string
+	items []ui.Drawable
+	// selected item or -1, if no item of this panel is selected
+	selected int
+	// offset is the first visible item, it is kept between draws
+	offset int
+}
+
+func newDevicePanel(title string) *devicePanel {
+	return &devicePanel{
+		Block:    *ui.NewBlock(),
+		title:    title,
+		selected: -1,
+	}
+}
+
+// visible returns the number of items fitting into the panel.
+func (p *devicePanel) visible() int {
+	if v := p.Inner.Dy() / deviceHeight; v > 0 {
+		return v
+	}
+	return 1
+}
+
+// scroll adjusts the offset, so the selected item is visible and no space is wasted.
+func (p *devicePanel) scroll() {
+	visible := p.visible()
+	if p.selected >= 0 {
+		if p.selected < p.offset {
+			p.offset = p.selected
+		}
+		if p.selected >= p.offset+visible {
+			p.offset = p.selected - visible + 1
+		}
+	}
+	if max := len(p.items) - visible; p.offset > max {
+		p.offset = max
+	}
+	if p.offset < 0 {
+		p.offset = 0
+	}
+}
+
+func (p *devicePanel) Draw(buf *ui.Buffer) {
+	p.scroll()
+	visible := p.visible()
+
+	p.Title = p.title
+	if len(p.items) > visible {
+		last := p.offset + visible
+		if last > len(p.items) {
+			last = len(p.items)
+		}
+		p.Title = fmt.Sprintf("%s (%d-%d of %d)", p.title, p.offset+1, last, len(p.items))
+	}
+	p.Block.Draw(buf)
+
+	for i := p.offset; i < len(p.items) && i < p.offset+visible; i++ {
+		y := p.Inner.Min.Y + (i-p.offset)*deviceHeight
+		item := p.items[i]
+		item.SetRect(p.Inner.Min.X, y, p.Inner.Max.X, y+deviceHeight)
+		item.Lock()
+		item.Draw(buf)
+		item.Unlock()
+	}
+}
+
+// shutterGauge shows the current position of a shutter as bar
+// and marks the target position, the shutter is moving to.
+type shutterGauge struct {
+	widgets.Gauge
+	Target int
+}
+
+func newShutterGauge(shutter smarthome.Shutter) *shutterGauge {
+	g := &shutterGauge{
+		Gauge:  *widgets.NewGauge(),
+		Target: shutter.Target,
+	}
+	g.Title = " " + shutter.Name + " "
+	if shutter.Moving {
+		g.Title = g.Title + "<moving> "
+	}
+	g.Percent = shutter.Current
+	g.Label = fmt.Sprintf("%d%%", shutter.Current)
+	if shutter.Target != shutter.Current {
+		g.Label = fmt.Sprintf("%d%% -> %d%%", shutter.Current, shutter.Target)
+	}
+	g.BarColor = ui.ColorBlue
+	g.LabelStyle = ui.NewStyle(ui.ColorBlue)
+	return g
+}
+
+func (g *shutterGauge) Draw(buf *ui.Buffer) {
+	g.Gauge.Draw(buf)
+	if g.Target == g.Percent || g.Inner.Dx() <= 0 {
+		return
+	}
+
+	// mark the target in the bar
+	x := g.Inner.Min.X + g.Target*(g.Inner.Dx()-1)/100
+	buf.Fill(
+		ui.NewCell('|', ui.NewStyle(ui.ColorYellow, ui.ColorClear, ui.ModifierBold)),
+		image.Rect(x, g.Inner.Min.Y, x+1, g.Inner.Max.Y),
+	)
+}
+
+func newLightParagraph(light smarthome.Light) *widgets.Paragraph {
+	p := widgets.NewParagraph()
+	p.Title = " " + light.Name + " "
+	p.Text = "off"
+	if light.On {
+		p.Text = "on"
+		p.TextStyle = ui.NewStyle(ui.ColorYellow, ui.ColorClear, ui.ModifierBold)
+	}
+	return p
+}
@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"testing"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+func TestDevicePanelScroll(t *testing.T) {
+	tests := []struct {
+		name           string
+		items          int
+		selected       int
+		offset         int
+		expectedOffset int
+	}{
+		{
+			name: "all visible", items: 3, selected: 2, offset: 0,
+			expectedOffset: 0,
+		},
+		{
+			name: "scroll down to selection", items: 10, selected: 6, offset: 0,
+			expectedOffset: 3,
+		},
+		{
+			name: "scroll up to selection", items: 10, selected: 1, offset: 5,
+			expectedOffset: 1,
+		},
+		{
+			name: "keep offset while selection is visible", items: 10, selected: 4, offset: 2,
+			expectedOffset: 2,
+		},
+		{
+			name: "no selection", items: 10, selected: -1, offset: 8,
+			expectedOffset: 6,
+		},
+		{
+			name: "fill space after devices are removed", items: 5, selected: 4, offset: 4,
+			expectedOffset: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := newDevicePanel("Shutters")
+			// 4 devices fit in, the border takes up 2 rows
+			p.SetRect(0, 0, 50, 4*deviceHeight+2)
+			for i := 0; i < test.items; i++ {
+				p.items = append(p.items, widgets.NewGauge())
+			}
+			p.selected = test.selected
+			p.offset = test.offset
+
+			p.Draw(ui.NewBuffer(p.GetRect()))
+			if p.offset != test.expectedOffset {
+				t.Errorf("expected offset %d, got %d", test.expectedOffset, p.offset)
+			}
+		})
+	}
+}
+
+func TestDevicesRatio(t *testing.T) {
+	tests := []struct {
+		name             string
+		shutters, lights int
+		termHeight       int
+		expectedRatio    float64
+	}{
+		{
+			name: "fits", shutters: 2, lights: 3, termHeight: 44,
+			expectedRatio: 11.0 / 44,
+		},
+		{
+			name: "limited to leave room for logs", shutters: 20, lights: 1, termHeight: 40,
+			expectedRatio: 0.6,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := devicesRatio(test.shutters, test.lights, test.termHeight)
+			if got != test.expectedRatio {
+				t.Errorf("expected %v, got %v", test.expectedRatio, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/go-logr/logr"
+)
+
+// defaultLogLines is the number of log lines kept by default.
+const defaultLogLines = 1000
+
+// errorLevel is the level of log entries logged with Error.
+const errorLevel = -1
+
+// LogOptions configures the log of the dashboards.
+type LogOptions struct {
+	// Lines is the number of log lines kept, older lines are dropped.
+	// Defaults to 1000.
+	Lines int
+	// Verbosity is the highest V level logged.
+	// Errors are always logged, 0 only adds Info.
+	Verbosity int
+	// Tee receives a copy of every line, e.g. a file or stderr.
+	Tee io.Writer
+}
+
+// logEntry is a single line in the log.
+type logEntry struct {
+	// level is the V level or errorLevel
+	level int
+	line  string
+}
+
+// style returns the line with a termui style, coloured by level.
+func (e logEntry) style() string {
+	switch {
+	case e.level == errorLevel:
+		return "[" + e.line + "](fg:red)"
+	case e.level > 0:
+		return "[" + e.line + "](fg:cyan)"
+	}
+	return e.line
+}
+
+// uiLogSink collects log lines for the dashboards in a ring buffer.
+type uiLogSink struct {
+	entries []logEntry
+	// total is the number of entries ever logged
+	total     int
+	size      int
+	verbosity int
+	tee       io.Writer
+	// updated is notified about new lines.
+	// Notifications are coalesced, so logging never blocks on a slow dashboard.
+	updated chan struct{}
+	sync.Mutex
+}
+
+func newUILogSink(opts LogOptions) *uiLogSink {
+	size := opts.Lines
+	if size <= 0 {
+		size = defaultLogLines
+	}
+	return &uiLogSink{
+		size:      size,
+		verbosity: opts.Verbosity,
+		tee:       opts.Tee,
+		updated:   make(chan struct{}, 1),
+	}
+}
+
+func (s *uiLogSink) Log(e logEntry) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.tee != nil {
+		// there is no place left to report failures to
+		_, _ = io.WriteString(s.tee, e.line+"\n")
+	}
+
+	if len(s.entries) < s.size {
+		s.entries = append(s.entries, e)
+	} else {
+		s.entries[s.total%s.size] = e
+	}
+	s.total++
+
+	select {
+	case s.updated <- struct{}{}:
+	default:
+	}
+}
+
+// lines returns a copy of all lines kept.
+func (s *uiLogSink) lines() []string {
+	entries, _ := s.since(0)
+	lines := make([]string, len(entries))
+	for i, e := range entries {
+		lines[i] = e.line
+	}
+	return lines
+}
+
+// since returns the entries logged after the first n entries
+// and the total number of entries logged.
+// Entries already dropped from the buffer are skipped.
+func (s *uiLogSink) since(n int) ([]logEntry, int) {
+	s.Lock()
+	defer s.Unlock()
+
+	if first := s.total - len(s.entries); n < first {
+		n = first
+	}
+	if n > s.total {
+		n = s.total
+	}
+	entries := make([]logEntry, 0, s.total-n)
+	for i := n; i < s.total; i++ {
+		entries = append(entries, s.entries[i%s.size])
+	}
+	return entries, s.total
+}
+
+var _ logr.Logger = (*uiLogger)(nil)
+
+type uiLogger struct {
+	sink   *uiLogSink
+	names  []string
+	values map[string]interface{}
+	level  int
+}
+
+func newUILogger(opts LogOptions) *uiLogger {
+	return &uiLogger{
+		sink: newUILogSink(opts),
+	}
+}
+
+func (l *uiLogger) Enabled() bool {
+	return l.level <= l.sink.verbosity
+}
+
+func (l *uiLogger) Info(msg string, kvs ...interface{}) {
+	if !l.Enabled() {
+		return
+	}
+	l.log(l.level, msg, kvs)
+}
+
+func (l *uiLogger) Error(err error, msg string, kvs ...interface{}) {
+	// controller-runtime logs some errors without an error value
+	if err != nil {
+		kvs = append(kvs, "error", err.Error())
+	}
+	l.log(errorLevel, msg, kvs)
+}
+
+func (l *uiLogger) log(level int, msg string, kvs []interface{}) {
+	values := addValues(l.values, kvs...)
+
+	j, err := json.Marshal(values)
+	if err != nil {
+		// e.g. channels or functions, still show what we have
+		j = []byte(fmt.Sprintf("%v", values))
+	}
+	l.sink.Log(logEntry{
+		level: level,
+		line: fmt.Sprintf("%-5s %-15s %-20s %s",
+			levelName(level), strings.Join(l.names, "."), msg, string(j)),
+	})
+}
+
+func levelName(level int) string {
+	switch {
+	case level == errorLevel:
+		return "ERROR"
+	case level > 0:
+		return "DEBUG"
+	}
+	return "INFO"
+}
+
+func (l *uiLogger) V(level int) logr.InfoLogger {
+	return &uiLogger{
+		sink:   l.sink,
+		names:  l.names,
+		values: l.values,
+		level:  level,
+	}
+}
+
+func (l *uiLogger) WithValues(kvs ...interface{}) logr.Logger {
+	return &uiLogger{
+		sink:   l.sink,
+		names:  l.names,
+		values: addValues(l.values, kvs...),
+		level:  l.level,
+	}
+}
+
+func (l *uiLogger) WithName(name string) logr.Logger {
+	// copy, so loggers derived from the same parent do not share names
+	names := make([]string, len(l.names), len(l.names)+1)
+	copy(names, l.names)
+	return &uiLogger{
+		sink:   l.sink,
+		names:  append(names, name),
+		values: l.values,
+		level:  l.level,
+	}
+}
+
+func addValues(base map[string]interface{}, kvs ...interface{}) map[string]interface{} {
+	values := map[string]interface{}{}
+	// add existing k/v pairs
+	for k := range base {
+		values[k] = base[k]
+	}
+	// add new k/v pairs
+	for i := 0; i < len(kvs); i += 2 {
+		if i+1 >= len(kvs) {
+			return values
+		}
+		values[fmt.Sprint(kvs[i])] = kvs[i+1]
+	}
+	return values
+}
@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestUILogSinkRingBuffer(t *testing.T) {
+	s := newUILogSink(LogOptions{Lines: 3})
+	for _, line := range []string{"a", "b", "c", "d", "e"} {
+		// nobody reads updated, this must not block
+		s.Log(logEntry{line: line})
+	}
+
+	if lines := s.lines(); !reflect.DeepEqual(lines, []string{"c", "d", "e"}) {
+		t.Errorf("expected the last 3 lines, got %v", lines)
+	}
+
+	tests := []struct {
+		name            string
+		n               int
+		expectedEntries []logEntry
+	}{
+		{
+			name:            "new entries",
+			n:               3,
+			expectedEntries: []logEntry{{line: "d"}, {line: "e"}},
+		},
+		{
+			name:            "dropped entries are skipped",
+			n:               1,
+			expectedEntries: []logEntry{{line: "c"}, {line: "d"}, {line: "e"}},
+		},
+		{
+			name:            "nothing new",
+			n:               5,
+			expectedEntries: []logEntry{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entries, total := s.since(test.n)
+			if !reflect.DeepEqual(entries, test.expectedEntries) {
+				t.Errorf("expected entries %v, got %v", test.expectedEntries, entries)
+			}
+			if total != 5 {
+				t.Errorf("expected 5 entries logged in total, got %d", total)
+			}
+		})
+	}
+}
+
+func TestUILogger(t *testing.T) {
+	tee := &bytes.Buffer{}
+	l := newUILogger(LogOptions{Verbosity: 1, Tee: tee})
+
+	log := l.WithName("controllers").WithValues("shutter", "default/bedroom")
+	log.Info("moving")
+	log.V(1).Info("details")
+	log.V(2).Info("too verbose")
+	log.Error(errors.New("unreachable"), "failed")
+	log.Error(nil, "no error")
+	// derived loggers must not share names
+	_ = l.WithName("a")
+	l.WithName("b").Info("names")
+
+	expectedEntries := []logEntry{
+		{level: 0, line: `INFO  controllers     moving               {"shutter":"default/bedroom"}`},
+		{level: 1, line: `DEBUG controllers     details              {"shutter":"default/bedroom"}`},
+		{level: errorLevel, line: `ERROR controllers     failed               {"error":"unreachable","shutter":"default/bedroom"}`},
+		{level: errorLevel, line: `ERROR controllers     no error             {"shutter":"default/bedroom"}`},
+		{level: 0, line: `INFO  b               names                {}`},
+	}
+	entries, _ := l.sink.since(0)
+	if !reflect.DeepEqual(entries, expectedEntries) {
+		t.Errorf("expected entries\n%v\ngot\n%v", expectedEntries, entries)
+	}
+
+	var expectedTee string
+	for _, e := range expectedEntries {
+		expectedTee += e.line + "\n"
+	}
+	if tee.String() != expectedTee {
+		t.Errorf("expected tee\n%s\ngot\n%s", expectedTee, tee.String())
+	}
+
+	if l.V(2).Enabled() {
+		t.Error("expected V(2) to be disabled")
+	}
+}
@@ -2,45 +2,77 @@ package ui
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
 	"log"
-	"strings"
 	"sync"
 	"time"
 
 	ui "github.com/gizak/termui/v3"
 	"github.com/gizak/termui/v3/widgets"
 	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/loodse/godays-2020-k8s-workshop/smart-home/pkg/smarthome"
 )
 
+// controlTimeout limits how long changing a device may take.
+const controlTimeout = 10 * time.Second
+
 type UI struct {
 	client  *smarthome.Client
+	control *deviceControl
 	redraw  time.Duration
 	closeCh chan struct{}
+	stopCh  chan struct{}
+	stop    sync.Once
 	logger  *uiLogger
-	log     ui.Drawable
+	logList *widgets.List
+	layout  ui.Drawable
+
+	shutterPanel, lightPanel *devicePanel
+	// devices as of the last draw, in display order
+	shutters []smarthome.Shutter
+	lights   []smarthome.Light
+	// selected device index, shutters first, then lights
+	selected int
 }
 
-func NewUI(client *smarthome.Client, redraw time.Duration) *UI {
+func NewUI(client *smarthome.Client, redraw time.Duration, logOpts LogOptions) *UI {
 	return &UI{
 		client:  client,
 		redraw:  redraw,
 		closeCh: make(chan struct{}),
-		logger:  newUILogger(),
+		stopCh:  make(chan struct{}),
+		logger:  newUILogger(logOpts),
+		logList: newLogList(),
+
+		shutterPanel: newDevicePanel("Shutters"),
+		lightPanel:   newDevicePanel("Lights"),
 	}
 }
 
+// EnableControl lets the dashboard change devices,
+// by patching their custom resources with the given client.
+// Without it the dashboard is read-only.
+func (u *UI) EnableControl(c client.Client) {
+	u.control = &deviceControl{client: c, shutters: u.client.Shutters()}
+}
+
 func (u *UI) Logger() logr.Logger {
 	return u.logger
 }
 
+// CloseCh is closed, after the UI was quit or stopped and the terminal was restored.
 func (u *UI) CloseCh() <-chan struct{} {
 	return u.closeCh
 }
 
+// Stop quits the UI, as if the user pressed q.
+func (u *UI) Stop() {
+	u.stop.Do(func() {
+		close(u.stopCh)
+	})
+}
+
 func (u *UI) Run() {
 	defer close(u.closeCh)
 
@@ -57,162 +89,239 @@ func (u *UI) Run() {
 	uiEvents := ui.PollEvents()
 	for {
 		select {
+		case <-u.stopCh:
+			return
+
 		case <-ticker.C:
 			u.draw()
 			continue
 
 		case <-u.logger.sink.updated:
+			u.updateLogList()
+			u.logList.ScrollBottom()
 
 		case e := <-uiEvents:
 			switch e.ID {
 			case "q", "<C-c>":
 				return
 			case "<Down>":
-				u.logger.sink.log.ScrollDown()
+				u.logList.ScrollDown()
 			case "<Up>":
-				u.logger.sink.log.ScrollUp()
+				u.logList.ScrollUp()
 			case "<Right>":
-				u.logger.sink.log.ScrollRight()
+				u.logList.ScrollRight()
 			case "<Left>":
-				u.logger.sink.log.ScrollLeft()
+				u.logList.ScrollLeft()
+			case "<Resize>":
+				u.draw()
+				continue
+			default:
+				if u.handleControl(e.ID) {
+					u.draw()
+					continue
+				}
 			}
-			ui.Render(u.log)
+			ui.Render(u.layout)
 		}
 	}
 }
 
-func (u *UI) draw() {
-	var elements []ui.Drawable
-
-	shuttersTitle := widgets.NewParagraph()
-	shuttersTitle.Text = "Shutters"
-	shuttersTitle.SetRect(0, 0, 50, 1)
-	shuttersTitle.Border = false
-
-	elements = append(elements, shuttersTitle)
-
-	shutters, _ := u.client.Shutters().List(context.Background())
-	pos := 2
-	for _, shutter := range shutters {
-		g := widgets.NewGauge()
-		g.Title = " " + shutter.Name + " "
-		if shutter.Moving {
-			g.Title = g.Title + "<moving> "
+// handleControl handles keys selecting and changing devices
+// and reports whether the key was handled.
+func (u *UI) handleControl(key string) bool {
+	devices := len(u.shutters) + len(u.lights)
+	switch key {
+	case "<Tab>", "j":
+		if devices > 0 {
+			u.selected = (u.selected + 1) % devices
 		}
-		g.Percent = shutter.Current
-		g.BarColor = ui.ColorBlue
-		g.LabelStyle = ui.NewStyle(ui.ColorBlue)
-		g.BorderStyle.Fg = ui.ColorWhite
-		g.SetRect(0, pos, 50, pos+3)
-		pos += 3
-
-		elements = append(elements, g)
+		return true
+	case "k":
+		if devices > 0 {
+			u.selected = (u.selected + devices - 1) % devices
+		}
+		return true
+	}
+	if u.control == nil {
+		return false
 	}
 
-	grid := ui.NewGrid()
-	termWidth, termHeight := ui.TerminalDimensions()
-	grid.SetRect(0, pos, termWidth, termHeight)
-	grid.Set(
-		ui.NewRow(1, u.logger.sink.log),
-	)
-	u.log = grid
-	elements = append(elements, grid)
+	if u.selected < len(u.shutters) {
+		shutter := u.shutters[u.selected]
+		switch key {
+		case "+":
+			u.act("close shutter by 10%", func(ctx context.Context) error {
+				return u.control.moveShutterBy(ctx, shutter, shutterStep)
+			})
+		case "-":
+			u.act("open shutter by 10%", func(ctx context.Context) error {
+				return u.control.moveShutterBy(ctx, shutter, -shutterStep)
+			})
+		case "o":
+			u.act("open shutter", func(ctx context.Context) error {
+				return u.control.moveShutterTo(ctx, shutter, 0)
+			})
+		case "c":
+			u.act("close shutter", func(ctx context.Context) error {
+				return u.control.moveShutterTo(ctx, shutter, 100)
+			})
+		case "s":
+			u.act("stop shutter", func(ctx context.Context) error {
+				return u.control.stopShutter(ctx, shutter)
+			})
+		default:
+			return false
+		}
+		return true
+	}
 
-	ui.Clear()
-	ui.Render(elements...)
+	if i := u.selected - len(u.shutters); i < len(u.lights) {
+		light := u.lights[i]
+		switch key {
+		case "<Space>", "<Enter>", "t":
+			u.act("toggle light", func(ctx context.Context) error {
+				return u.control.toggleLight(ctx, light)
+			})
+			return true
+		}
+	}
+	return false
 }
 
-type uiLogSink struct {
-	log     *widgets.List
-	updated chan struct{}
-	sync.Mutex
+// act runs the action in the background, so a slow API server does not block the dashboard.
+func (u *UI) act(msg string, action func(ctx context.Context) error) {
+	log := u.logger.WithName("ui")
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), controlTimeout)
+		defer cancel()
+		if err := action(ctx); err != nil {
+			log.Error(err, "unable to "+msg)
+			return
+		}
+		log.Info(msg)
+	}()
 }
 
-func newUILogSink() *uiLogSink {
-	log := widgets.NewList()
-	log.Title = "Logs"
-	log.TextStyle = ui.NewStyle(ui.ColorWhite)
-	log.SelectedRowStyle = ui.NewStyle(ui.ColorBlue)
-	log.WrapText = false
-
-	return &uiLogSink{
-		log:     log,
-		updated: make(chan struct{}, 100),
+func (u *UI) draw() {
+	shutters, _ := u.client.Shutters().List(context.Background())
+	lights, _ := u.client.Lights().List(context.Background())
+	u.shutters, u.lights = shutters, lights
+	if devices := len(shutters) + len(lights); u.selected >= devices {
+		u.selected = 0
 	}
-}
 
-func (s *uiLogSink) Log(line string) {
-	s.Lock()
-	defer s.Unlock()
-	s.log.Rows = append(s.log.Rows, line)
-	s.updated <- struct{}{}
-}
+	u.shutterPanel.items = nil
+	u.shutterPanel.selected = -1
+	for i, shutter := range shutters {
+		g := newShutterGauge(shutter)
+		g.BorderStyle.Fg = u.borderColor(i)
+		u.shutterPanel.items = append(u.shutterPanel.items, g)
+		if i == u.selected {
+			u.shutterPanel.selected = i
+		}
+	}
 
-var _ logr.Logger = (*uiLogger)(nil)
+	u.lightPanel.items = nil
+	u.lightPanel.selected = -1
+	for i, light := range lights {
+		p := newLightParagraph(light)
+		p.BorderStyle.Fg = u.borderColor(len(shutters) + i)
+		u.lightPanel.items = append(u.lightPanel.items, p)
+		if len(shutters)+i == u.selected {
+			u.lightPanel.selected = i
+		}
+	}
 
-type uiLogger struct {
-	sink   *uiLogSink
-	names  []string
-	values map[string]interface{}
-}
+	u.updateLogList()
 
-func newUILogger() *uiLogger {
-	return &uiLogger{
-		sink: newUILogSink(),
+	termWidth, termHeight := ui.TerminalDimensions()
+	grid := ui.NewGrid()
+	grid.SetRect(0, 0, termWidth, termHeight)
+	devices := devicesRatio(len(shutters), len(lights), termHeight)
+	switch {
+	case termWidth < 60:
+		// stack panels on narrow terminals
+		grid.Set(
+			ui.NewRow(devices/2, u.shutterPanel),
+			ui.NewRow(devices/2, u.lightPanel),
+			ui.NewRow(1-devices, u.logList),
+		)
+	case termWidth >= 100 && u.control != nil:
+		grid.Set(
+			ui.NewRow(devices,
+				ui.NewCol(0.5, u.shutterPanel),
+				ui.NewCol(0.3, u.lightPanel),
+				ui.NewCol(0.2, newKeysParagraph()),
+			),
+			ui.NewRow(1-devices, u.logList),
+		)
+	default:
+		grid.Set(
+			ui.NewRow(devices,
+				ui.NewCol(0.6, u.shutterPanel),
+				ui.NewCol(0.4, u.lightPanel),
+			),
+			ui.NewRow(1-devices, u.logList),
+		)
 	}
-}
+	u.layout = grid
 
-func (l *uiLogger) Enabled() bool {
-	return true
+	ui.Clear()
+	ui.Render(grid)
 }
 
-func (l *uiLogger) Info(msg string, kvs ...interface{}) {
-	values := addValues(l.values, kvs...)
-
-	j, err := json.Marshal(values)
-	if err != nil {
-		panic(err)
+// devicesRatio returns the share of the terminal height for the device panels.
+// The panels grow with the number of devices, but leave room for the logs.
+func devicesRatio(shutters, lights, termHeight int) float64 {
+	if termHeight <= 0 {
+		return 0.5
 	}
-	l.sink.Log(fmt.Sprintf("%-15s %-20s %s", strings.Join(l.names, "."), msg, string(j)))
-	l.sink.log.ScrollBottom()
-}
-
-func (l *uiLogger) Error(err error, msg string, kvs ...interface{}) {
-	l.Info(msg, append(kvs, "error", err.Error())...)
+	devices := shutters
+	if lights > devices {
+		devices = lights
+	}
+	needed := float64(devices*deviceHeight + 2)
+	if max := float64(termHeight) * 0.6; needed > max {
+		needed = max
+	}
+	return needed / float64(termHeight)
 }
 
-func (l *uiLogger) V(level int) logr.InfoLogger {
-	return l
+func newKeysParagraph() *widgets.Paragraph {
+	p := widgets.NewParagraph()
+	p.Title = "Keys"
+	p.Text = "Tab/j/k select\n" +
+		"+/-     10%\n" +
+		"o/c     open/close\n" +
+		"s       stop\n" +
+		"Space   light\n" +
+		"q       quit"
+	return p
 }
 
-func (l *uiLogger) WithValues(kvs ...interface{}) logr.Logger {
-	return &uiLogger{
-		sink:   l.sink,
-		names:  l.names,
-		values: addValues(l.values, kvs),
+// borderColor highlights the selected device.
+func (u *UI) borderColor(device int) ui.Color {
+	if device == u.selected {
+		return ui.ColorGreen
 	}
+	return ui.ColorWhite
 }
 
-func (l *uiLogger) WithName(name string) logr.Logger {
-	return &uiLogger{
-		sink:   l.sink,
-		names:  append(l.names, name),
-		values: l.values,
+// updateLogList shows all log entries, coloured by level.
+func (u *UI) updateLogList() {
+	entries, _ := u.logger.sink.since(0)
+	rows := make([]string, len(entries))
+	for i, e := range entries {
+		rows[i] = e.style()
 	}
+	u.logList.Rows = rows
 }
 
-func addValues(base map[string]interface{}, kvs ...interface{}) map[string]interface{} {
-	values := map[string]interface{}{}
-	// add existing k/v pairs
-	for k := range base {
-		values[k] = base[k]
-	}
-	// add new k/v pairs
-	for i := 0; i < len(kvs); i += 2 {
-		if i+1 >= len(kvs) {
-			return values
-		}
-		values[fmt.Sprint(kvs[i])] = kvs[i+1]
-	}
-	return values
+func newLogList() *widgets.List {
+	log := widgets.NewList()
+	log.Title = "Logs"
+	log.TextStyle = ui.NewStyle(ui.ColorWhite)
+	log.SelectedRowStyle = ui.NewStyle(ui.ColorBlue)
+	log.WrapText = false
+	return log
 }
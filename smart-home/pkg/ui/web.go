@@ -0,0 +1,259 @@
+package ui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/loodse/godays-2020-k8s-workshop/smart-home/pkg/smarthome"
+)
+
+// webClientBuffer is the number of events buffered per event stream.
+// Events for clients not keeping up are dropped.
+const webClientBuffer = 64
+
+// Web is a dashboard served over HTTP,
+// for running without a terminal, e.g. in a pod.
+//
+// It serves:
+// /             a HTML page
+// /api/devices  the state of all shutters and lights as JSON
+// /api/logs     all log lines as JSON
+// /api/events   a Server-Sent Events stream of "devices" and "log" events
+type Web struct {
+	client *smarthome.Client
+	poll   time.Duration
+	logger *uiLogger
+
+	clients    map[chan webEvent]struct{}
+	clientsMux sync.Mutex
+}
+
+// deviceState is the state of all devices, as served by the web dashboard.
+type deviceState struct {
+	Shutters []shutterState `json:"shutters"`
+	Lights   []lightState   `json:"lights"`
+}
+
+type shutterState struct {
+	Name    string `json:"name"`
+	Target  int    `json:"target"`
+	Current int    `json:"current"`
+	Moving  bool   `json:"moving"`
+}
+
+type lightState struct {
+	Name string `json:"name"`
+	On   bool   `json:"on"`
+}
+
+type webEvent struct {
+	name string
+	data interface{}
+}
+
+// NewWeb returns a web dashboard, polling device state in the given interval.
+func NewWeb(client *smarthome.Client, poll time.Duration, logOpts LogOptions) *Web {
+	return &Web{
+		client:  client,
+		poll:    poll,
+		logger:  newUILogger(logOpts),
+		clients: map[chan webEvent]struct{}{},
+	}
+}
+
+func (w *Web) Logger() logr.Logger {
+	return w.logger
+}
+
+// Handler returns the HTTP handler serving the dashboard.
+func (w *Web) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", w.serveIndex)
+	mux.HandleFunc("/api/devices", w.serveDevices)
+	mux.HandleFunc("/api/logs", w.serveLogs)
+	mux.HandleFunc("/api/events", w.serveEvents)
+	return mux
+}
+
+// Start implements manager.Runnable.
+// It publishes device changes and log lines to all event streams, until stop is closed.
+func (w *Web) Start(stop <-chan struct{}) error {
+	ticker := time.NewTicker(w.poll)
+	defer ticker.Stop()
+
+	var (
+		last    deviceState
+		logged  int
+		entries []logEntry
+		devices deviceState
+		err     error
+	)
+	// only publish changes, clients get the current state when connecting
+	last, _ = w.devices(context.Background())
+	for {
+		select {
+		case <-stop:
+			w.closeClients()
+			return nil
+
+		case <-ticker.C:
+			devices, err = w.devices(context.Background())
+			if err != nil || reflect.DeepEqual(devices, last) {
+				continue
+			}
+			last = devices
+			w.publish(webEvent{name: "devices", data: devices})
+
+		case <-w.logger.sink.updated:
+			entries, logged = w.logger.sink.since(logged)
+			for _, e := range entries {
+				w.publish(webEvent{name: "log", data: e.line})
+			}
+		}
+	}
+}
+
+func (w *Web) devices(ctx context.Context) (deviceState, error) {
+	shutters, err := w.client.Shutters().List(ctx)
+	if err != nil {
+		return deviceState{}, fmt.Errorf("listing shutters: %w", err)
+	}
+	lights, err := w.client.Lights().List(ctx)
+	if err != nil {
+		return deviceState{}, fmt.Errorf("listing lights: %w", err)
+	}
+
+	state := deviceState{
+		Shutters: []shutterState{},
+		Lights:   []lightState{},
+	}
+	for _, s := range shutters {
+		state.Shutters = append(state.Shutters, shutterState{
+			Name: s.Name, Target: s.Target, Current: s.Current, Moving: s.Moving,
+		})
+	}
+	for _, l := range lights {
+		state.Lights = append(state.Lights, lightState{Name: l.Name, On: l.On})
+	}
+	return state, nil
+}
+
+func (w *Web) publish(e webEvent) {
+	w.clientsMux.Lock()
+	defer w.clientsMux.Unlock()
+	for c := range w.clients {
+		select {
+		case c <- e:
+		default:
+		}
+	}
+}
+
+func (w *Web) subscribe() chan webEvent {
+	c := make(chan webEvent, webClientBuffer)
+	w.clientsMux.Lock()
+	defer w.clientsMux.Unlock()
+	w.clients[c] = struct{}{}
+	return c
+}
+
+func (w *Web) unsubscribe(c chan webEvent) {
+	w.clientsMux.Lock()
+	defer w.clientsMux.Unlock()
+	if _, ok := w.clients[c]; ok {
+		delete(w.clients, c)
+		close(c)
+	}
+}
+
+func (w *Web) closeClients() {
+	w.clientsMux.Lock()
+	defer w.clientsMux.Unlock()
+	for c := range w.clients {
+		delete(w.clients, c)
+		close(c)
+	}
+}
+
+func (w *Web) serveIndex(rw http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(rw, r)
+		return
+	}
+	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = rw.Write([]byte(webIndex))
+}
+
+func (w *Web) serveDevices(rw http.ResponseWriter, r *http.Request) {
+	devices, err := w.devices(r.Context())
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	writeJSON(rw, devices)
+}
+
+func (w *Web) serveLogs(rw http.ResponseWriter, r *http.Request) {
+	writeJSON(rw, w.logger.sink.lines())
+}
+
+func (w *Web) serveEvents(rw http.ResponseWriter, r *http.Request) {
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		http.Error(rw, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	events := w.subscribe()
+	defer w.unsubscribe(events)
+
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("Connection", "keep-alive")
+
+	// start with the current state, instead of waiting for the next change
+	if devices, err := w.devices(r.Context()); err == nil {
+		if err := writeEvent(rw, webEvent{name: "devices", data: devices}); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := writeEvent(rw, e); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeEvent(rw http.ResponseWriter, e webEvent) error {
+	data, err := json.Marshal(e.data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(rw, "event: %s\ndata: %s\n\n", e.name, data)
+	return err
+}
+
+func writeJSON(rw http.ResponseWriter, v interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(v); err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,84 @@
+package ui
+
+// webIndex is the page of the web dashboard.
+// It loads the logs once and follows the event stream afterwards.
+const webIndex = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Smart Home</title>
+<style>
+body { font-family: sans-serif; margin: 1em; background: #111; color: #eee; }
+h2 { font-size: 1.1em; }
+.devices { display: flex; flex-wrap: wrap; gap: 1em; }
+.panel { flex: 1 1 20em; }
+.device { border: 1px solid #555; padding: .4em; margin-bottom: .4em; }
+.bar { position: relative; height: 1.2em; background: #333; }
+.current { height: 100%; background: #3465a4; }
+.target { position: absolute; top: 0; width: 2px; height: 100%; background: #edd400; }
+.on { color: #edd400; font-weight: bold; }
+#logs { height: 40vh; overflow: auto; white-space: pre; font-family: monospace; border: 1px solid #555; padding: .4em; }
+</style>
+</head>
+<body>
+<div class="devices">
+  <div class="panel"><h2>Shutters</h2><div id="shutters"></div></div>
+  <div class="panel"><h2>Lights</h2><div id="lights"></div></div>
+</div>
+<h2>Logs</h2>
+<div id="logs"></div>
+<script>
+function el(tag, cls, text) {
+  var e = document.createElement(tag);
+  if (cls) e.className = cls;
+  if (text !== undefined) e.textContent = text;
+  return e;
+}
+
+function render(state) {
+  var shutters = document.getElementById("shutters");
+  shutters.textContent = "";
+  state.shutters.forEach(function (s) {
+    var d = el("div", "device");
+    var label = s.name + ": " + s.current + "%";
+    if (s.target !== s.current) label += " -> " + s.target + "%";
+    if (s.moving) label += " <moving>";
+    d.appendChild(el("div", "", label));
+    var bar = el("div", "bar");
+    var current = el("div", "current");
+    current.style.width = s.current + "%";
+    var target = el("div", "target");
+    target.style.left = s.target + "%";
+    bar.appendChild(current);
+    bar.appendChild(target);
+    d.appendChild(bar);
+    shutters.appendChild(d);
+  });
+
+  var lights = document.getElementById("lights");
+  lights.textContent = "";
+  state.lights.forEach(function (l) {
+    var d = el("div", "device", l.name + ": ");
+    d.appendChild(el("span", l.on ? "on" : "", l.on ? "on" : "off"));
+    lights.appendChild(d);
+  });
+}
+
+function log(line) {
+  var logs = document.getElementById("logs");
+  var follow = logs.scrollTop + logs.clientHeight >= logs.scrollHeight - 5;
+  logs.appendChild(document.createTextNode(line + "\n"));
+  if (follow) logs.scrollTop = logs.scrollHeight;
+}
+
+fetch("api/logs").then(function (r) { return r.json(); }).then(function (lines) {
+  lines.forEach(log);
+  var events = new EventSource("api/events");
+  events.addEventListener("devices", function (e) { render(JSON.parse(e.data)); });
+  events.addEventListener("log", function (e) { log(JSON.parse(e.data)); });
+});
+</script>
+</body>
+</html>
+`
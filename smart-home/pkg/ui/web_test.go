@@ -0,0 +1,168 @@
+package ui
+
+import (
+	"bufio"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/loodse/godays-2020-k8s-workshop/smart-home/pkg/smarthome"
+)
+
+func newTestWeb(t *testing.T) (*Web, func()) {
+	client, err := smarthome.NewClient(smarthome.SimulatorBackend, smarthome.BackendOptions{
+		Inventory: smarthome.Inventory{
+			Shutters: []string{"default/living-room"},
+			Lights:   []string{"default/bedroom"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewWeb(client, 10*time.Millisecond, LogOptions{}), func() { client.Close() }
+}
+
+func TestWebAPI(t *testing.T) {
+	w, cleanup := newTestWeb(t)
+	defer cleanup()
+	w.Logger().WithName("test").Info("hello")
+	server := httptest.NewServer(w.Handler())
+	defer server.Close()
+
+	tests := []struct {
+		path                string
+		expectedStatus      int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			path:                "/api/devices",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json",
+			expectedBody: `{"shutters":[{"name":"default/living-room","target":0,"current":0,"moving":false}],` +
+				`"lights":[{"name":"default/bedroom","on":false}]}`,
+		},
+		{
+			path:                "/api/logs",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json",
+			expectedBody:        `["INFO  test            hello                {}"]`,
+		},
+		{
+			path:                "/",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/html; charset=utf-8",
+		},
+		{
+			path:           "/typo",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			resp, err := http.Get(server.URL + test.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.StatusCode != test.expectedStatus {
+				t.Errorf("expected status %d, got %d", test.expectedStatus, resp.StatusCode)
+			}
+			if test.expectedContentType != "" && resp.Header.Get("Content-Type") != test.expectedContentType {
+				t.Errorf("expected content type %q, got %q", test.expectedContentType, resp.Header.Get("Content-Type"))
+			}
+			if test.expectedBody != "" && strings.TrimSpace(string(body)) != test.expectedBody {
+				t.Errorf("expected body %s, got %s", test.expectedBody, body)
+			}
+		})
+	}
+}
+
+func TestWebEvents(t *testing.T) {
+	w, cleanup := newTestWeb(t)
+	defer cleanup()
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() { done <- w.Start(stop) }()
+	server := httptest.NewServer(w.Handler())
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/api/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("expected event stream, got %q", ct)
+	}
+	events := bufio.NewReader(resp.Body)
+
+	// the current device state is sent right away
+	expectEvent(t, events, "devices", `{"shutters":[{"name":"default/living-room"`)
+
+	w.Logger().Info("hello")
+	expectEvent(t, events, "log", `"INFO                  hello                {}"`)
+
+	if err := w.client.Shutters().Set(ctx, "default/living-room", 100); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, events, "devices", `{"shutters":[{"name":"default/living-room","target":100`)
+
+	// stopping the dashboard ends all event streams
+	close(stop)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := events.ReadString('\n'); err == nil {
+		t.Error("expected the event stream to end")
+	}
+}
+
+// expectEvent reads the next event with the given name and checks the prefix of its data.
+func expectEvent(t *testing.T, events *bufio.Reader, name, dataPrefix string) {
+	t.Helper()
+
+	var gotName, gotData string
+	for gotName != name {
+		gotName, gotData = readEvent(t, events)
+	}
+	if !strings.HasPrefix(gotData, dataPrefix) {
+		t.Fatalf("expected event %q with data %s..., got data %s", name, dataPrefix, gotData)
+	}
+}
+
+func readEvent(t *testing.T, events *bufio.Reader) (name, data string) {
+	t.Helper()
+
+	for {
+		line, err := events.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading event: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			return name, data
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}